@@ -1,16 +1,29 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/chall-goflutter-api/api/handler"
+	"github.com/chall-goflutter-api/api/middleware"
+	"github.com/chall-goflutter-api/internal/budget"
 	"github.com/chall-goflutter-api/internal/interaction"
 	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/notification"
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/internal/payment"
+	"github.com/chall-goflutter-api/internal/scheduler"
+	"github.com/chall-goflutter-api/internal/shift"
 	"github.com/chall-goflutter-api/internal/stand"
 	"github.com/chall-goflutter-api/internal/ticket"
 	"github.com/chall-goflutter-api/internal/tombola"
 	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/mailer"
+	"github.com/chall-goflutter-api/pkg/storage"
+	"github.com/chall-goflutter-api/pkg/utils"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/cors"
@@ -36,37 +49,115 @@ func (s *APIServer) Start() error {
 		w.Write([]byte("OK"))
 	}).Methods(http.MethodGet)
 
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "uploads"
+	}
+	files, err := storage.NewLocal(storageDir, os.Getenv("STORAGE_PUBLIC_URL"), os.Getenv("STORAGE_SECRET"))
+	if err != nil {
+		return err
+	}
+	var mails mailer.Mailer = mailer.NewLog()
+	if os.Getenv("SMTP_HOST") != "" {
+		mails = mailer.NewSMTP(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+	}
+
+	organisationStore := organisation.NewStore(s.db)
+	organisationService := organisation.NewService(organisationStore)
+	organisationHandler := handler.NewOrganisationHandler(organisationService)
+	organisationHandler.RegisterRoutes(router)
+	router.Use(middleware.Tenant(organisationStore))
+
+	fileHandler := handler.NewFileHandler(files)
+	fileHandler.RegisterRoutes(router)
+
 	userStore := user.NewStore(s.db)
-	userService := user.NewService(userStore)
+	userService := user.NewService(userStore, organisationStore)
 	userHandler := handler.NewUserHandler(userService, userStore)
 	userHandler.RegisterRoutes(router)
 
+	notificationStore := notification.NewStore(s.db)
+
 	standStore := stand.NewStore(s.db)
-	standService := stand.NewService(standStore)
+	standService := stand.NewService(standStore, files)
 	standHandler := handler.NewStandHandler(standService, userStore)
 	standHandler.RegisterRoutes(router)
 
 	kermesseStore := kermesse.NewStore(s.db)
-	kermesseService := kermesse.NewService(kermesseStore, userStore)
+	kermesseService := kermesse.NewService(kermesseStore, userStore, notificationStore, files, mails)
 	kermesseHandler := handler.NewKermesseHandler(kermesseService, userStore)
 	kermesseHandler.RegisterRoutes(router)
 
+	shiftStore := shift.NewStore(s.db)
+	shiftService := shift.NewService(shiftStore, kermesseStore, notificationStore, mails)
+	shiftHandler := handler.NewShiftHandler(shiftService, userStore)
+	shiftHandler.RegisterRoutes(router)
+
+	offlineTokenSecret, err := utils.RequireEnv("OFFLINE_TOKEN_SECRET")
+	if err != nil {
+		return err
+	}
 	interactionStore := interaction.NewStore(s.db)
-	interactionService := interaction.NewService(interactionStore, standStore, userStore, kermesseStore)
+	interactionService := interaction.NewService(interactionStore, standStore, userStore, kermesseStore, shiftStore, offlineTokenSecret)
 	interactionHandler := handler.NewInteractionHandler(interactionService, userStore)
 	interactionHandler.RegisterRoutes(router)
 
+	paymentRequestSecret, err := utils.RequireEnv("PAYMENT_REQUEST_SECRET")
+	if err != nil {
+		return err
+	}
+	paymentStore := payment.NewStore(s.db)
+	paymentService := payment.NewService(paymentStore, standStore, interactionService, paymentRequestSecret)
+	paymentHandler := handler.NewPaymentHandler(paymentService, userStore)
+	paymentHandler.RegisterRoutes(router)
+
 	tombolaStore := tombola.NewStore(s.db)
 	tombolaService := tombola.NewService(tombolaStore, kermesseStore)
 	tombolaHandler := handler.NewTombolaHandler(tombolaService, userStore)
 	tombolaHandler.RegisterRoutes(router)
 
 	ticketStore := ticket.NewStore(s.db)
-	ticketService := ticket.NewService(ticketStore, tombolaStore, userStore)
+	ticketService := ticket.NewService(ticketStore, tombolaStore)
 	ticketHandler := handler.NewTicketHandler(ticketService, userStore)
 	ticketHandler.RegisterRoutes(router)
 
-	router.HandleFunc("/webhook", handler.HandleWebhook(userService)).Methods(http.MethodPost)
+	budgetStore := budget.NewStore(s.db)
+	budgetService := budget.NewService(budgetStore, kermesseStore, files)
+	budgetHandler := handler.NewBudgetHandler(budgetService, userStore)
+	budgetHandler.RegisterRoutes(router)
+
+	notificationService := notification.NewService(notificationStore)
+	notificationHandler := handler.NewNotificationHandler(notificationService, userStore)
+	notificationHandler.RegisterRoutes(router)
+
+	schedulerStore := scheduler.NewStore(s.db)
+	schedulerService := scheduler.NewService(schedulerStore)
+	schedulerHandler := handler.NewSchedulerHandler(schedulerService, userStore)
+	schedulerHandler.RegisterRoutes(router)
+
+	interactionSweeper := interaction.NewSweeper(interactionStore, utils.GetEnvInt("INTERACTION_TIMEOUT_MINUTES", 30))
+	jobs := scheduler.NewScheduler(schedulerStore)
+	retentionDays := utils.GetEnvInt("KERMESSE_RETENTION_DAYS", 365)
+	shiftReminderMinutes := utils.GetEnvInt("SHIFT_REMINDER_MINUTES", 60)
+	registrations := []error{
+		jobs.Register("interactions.sweep", time.Duration(utils.GetEnvInt("INTERACTION_SWEEP_INTERVAL", 60))*time.Second, interactionSweeper.Sweep),
+		jobs.Register("kermesses.open", time.Duration(utils.GetEnvInt("KERMESSE_SCHEDULE_INTERVAL", 60))*time.Second, kermesseService.OpenScheduled),
+		jobs.Register("kermesses.close", time.Duration(utils.GetEnvInt("KERMESSE_SCHEDULE_INTERVAL", 60))*time.Second, kermesseService.CloseScheduled),
+		jobs.Register("kermesses.retention", time.Duration(utils.GetEnvInt("KERMESSE_RETENTION_INTERVAL", 86400))*time.Second, func(ctx context.Context) (string, error) {
+			return kermesseService.AnonymiseArchived(ctx, retentionDays)
+		}),
+		jobs.Register("shifts.reminders", time.Duration(utils.GetEnvInt("SHIFT_REMINDER_INTERVAL", 60))*time.Second, func(ctx context.Context) (string, error) {
+			return shiftService.SendReminders(ctx, shiftReminderMinutes)
+		}),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+	jobs.Run(context.Background())
+
+	router.HandleFunc("/webhook", handler.HandleWebhook(budgetService)).Methods(http.MethodPost)
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
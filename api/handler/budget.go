@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/chall-goflutter-api/api/middleware"
+	"github.com/chall-goflutter-api/internal/budget"
+	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/json"
+	"github.com/gorilla/mux"
+)
+
+type BudgetHandler struct {
+	service   budget.BudgetService
+	userStore user.UserStore
+}
+
+func NewBudgetHandler(service budget.BudgetService, userStore user.UserStore) *BudgetHandler {
+	return &BudgetHandler{
+		service:   service,
+		userStore: userStore,
+	}
+}
+
+func (h *BudgetHandler) RegisterRoutes(mux *mux.Router) {
+	mux.Handle("/kermesses/{id}/budget", errors.ErrorHandler(middleware.IsAuth(h.GetReport, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/expenses", errors.ErrorHandler(middleware.IsAuth(h.GetExpenses, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/expenses", errors.ErrorHandler(middleware.IsAuth(h.CreateExpense, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/expenses/{id}/receipt", errors.ErrorHandler(middleware.IsAuth(h.UploadReceipt, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/expenses/{id}", errors.ErrorHandler(middleware.IsAuth(h.DeleteExpense, h.userStore))).Methods(http.MethodDelete)
+	mux.Handle("/kermesses/{id}/payments", errors.ErrorHandler(middleware.IsAuth(h.GetPayments, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/payments", errors.ErrorHandler(middleware.IsAuth(h.CreateCashPayment, h.userStore))).Methods(http.MethodPost)
+}
+
+func (h *BudgetHandler) GetReport(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	report, err := h.service.GetReport(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, report); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) GetExpenses(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	expenses, err := h.service.GetExpenses(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, expenses); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) CreateExpense(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	expense, err := h.service.CreateExpense(r.Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, expense); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) UploadReceipt(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	data, err := readFile(w, r, budget.MaxReceiptSize)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.UploadReceipt(r.Context(), id, data); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.DeleteExpense(r.Context(), id); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) GetPayments(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	payments, err := h.service.GetPayments(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, payments); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) CreateCashPayment(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.CreateCashPayment(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
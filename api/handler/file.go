@@ -0,0 +1,115 @@
+package handler
+
+import (
+	goErrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/imaging"
+	"github.com/chall-goflutter-api/pkg/storage"
+	"github.com/gorilla/mux"
+)
+
+type FileHandler struct {
+	files storage.Storage
+}
+
+func NewFileHandler(files storage.Storage) *FileHandler {
+	return &FileHandler{
+		files: files,
+	}
+}
+
+func (h *FileHandler) RegisterRoutes(mux *mux.Router) {
+	// Pas d'authentification : l'accès est contrôlé par la signature de l'URL
+	mux.Handle("/files/{key:.+}", errors.ErrorHandler(h.Get)).Methods(http.MethodGet)
+}
+
+func (h *FileHandler) Get(w http.ResponseWriter, r *http.Request) error {
+	key := mux.Vars(r)["key"]
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || !h.files.Verify(key, expires, r.URL.Query().Get("signature")) {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("URL invalide ou expirée"),
+		}
+	}
+
+	file, err := h.files.Open(key)
+	if err != nil {
+		if goErrors.Is(err, os.ErrNotExist) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(key)))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, file)
+
+	return err
+}
+
+// Lit l'image envoyée dans le champ "file" d'un formulaire multipart.
+func readImage(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	data, err := readFile(w, r, imaging.MaxSize)
+	if e, ok := err.(errors.CustomError); ok && e.Err == errFileTooLarge {
+		return nil, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: imaging.ErrTooLarge,
+		}
+	}
+
+	return data, err
+}
+
+var errFileTooLarge = goErrors.New("Le fichier est trop volumineux")
+
+// Lit le fichier envoyé dans le champ "file" d'un formulaire multipart, dans la limite de maxSize octets.
+func readFile(w http.ResponseWriter, r *http.Request, maxSize int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if goErrors.As(err, &maxBytesErr) {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: errFileTooLarge,
+			}
+		}
+		return nil, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Fichier manquant"),
+		}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: errFileTooLarge,
+		}
+	}
+
+	return data, nil
+}
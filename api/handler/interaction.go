@@ -30,7 +30,10 @@ func (h *InteractionHandler) RegisterRoutes(mux *mux.Router) {
 	mux.Handle("/interactions", errors.ErrorHandler(middleware.IsAuth(h.GetAll, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/interactions/{id}", errors.ErrorHandler(middleware.IsAuth(h.Get, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/interactions", errors.ErrorHandler(middleware.IsAuth(h.Create, h.userStore, types.UserRoleParent, types.UserRoleEnfant))).Methods(http.MethodPost)
-	mux.Handle("/interactions/{id}", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPatch)
+	mux.Handle("/interactions/offline-tokens", errors.ErrorHandler(middleware.IsAuth(h.IssueOfflineToken, h.userStore, types.UserRoleParent, types.UserRoleEnfant))).Methods(http.MethodPost)
+	mux.Handle("/interactions/{id}", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore, types.UserRoleTeneurStand, types.UserRoleParent))).Methods(http.MethodPatch)
+	mux.Handle("/interactions/{id}/refund", errors.ErrorHandler(middleware.IsAuth(h.Refund, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/stands/{id}/sync", errors.ErrorHandler(middleware.IsAuth(h.Sync, h.userStore, types.UserRoleTeneurStand, types.UserRoleParent))).Methods(http.MethodPost)
 }
 
 func (h *InteractionHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
@@ -128,3 +131,86 @@ func (h *InteractionHandler) Update(w http.ResponseWriter, r *http.Request) erro
 
 	return nil
 }
+
+func (h *InteractionHandler) Sync(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input struct {
+		Interactions []map[string]interface{} `json:"interactions"`
+	}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	results, err := h.service.Sync(r.Context(), id, input.Interactions)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, results); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *InteractionHandler) IssueOfflineToken(w http.ResponseWriter, r *http.Request) error {
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	token, err := h.service.IssueOfflineToken(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, token); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *InteractionHandler) Refund(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Refund(r.Context(), id); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"bytes"
+	encodingJson "encoding/json"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/chall-goflutter-api/api/middleware"
 	"github.com/chall-goflutter-api/internal/kermesse"
@@ -10,6 +14,8 @@ import (
 	"github.com/chall-goflutter-api/internal/user"
 	"github.com/chall-goflutter-api/pkg/errors"
 	"github.com/chall-goflutter-api/pkg/json"
+	"github.com/chall-goflutter-api/pkg/sse"
+	"github.com/chall-goflutter-api/pkg/utils"
 	"github.com/gorilla/mux"
 )
 
@@ -27,17 +33,40 @@ func NewKermesseHandler(service kermesse.KermesseService, userStore user.UserSto
 
 func (h *KermesseHandler) RegisterRoutes(mux *mux.Router) {
 	mux.Handle("/kermesses", errors.ErrorHandler(middleware.IsAuth(h.GetAll, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/templates", errors.ErrorHandler(middleware.IsAuth(h.GetTemplates, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodGet)
 	mux.Handle("/kermesses/{id}", errors.ErrorHandler(middleware.IsAuth(h.Get, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/kermesses/{id}/users", errors.ErrorHandler(middleware.IsAuth(h.GetUsersInvite, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/kermesses", errors.ErrorHandler(middleware.IsAuth(h.Create, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPost)
-	mux.Handle("/kermesses/{id}", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPatch)
+	mux.Handle("/kermesses/{id}", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore))).Methods(http.MethodPatch)
+	mux.Handle("/kermesses/{id}/clone", errors.ErrorHandler(middleware.IsAuth(h.Clone, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPost)
+	mux.Handle("/kermesses/{id}/template", errors.ErrorHandler(middleware.IsAuth(h.SaveTemplate, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPost)
 	mux.Handle("/kermesses/{id}/participant", errors.ErrorHandler(middleware.IsAuth(h.AddParticipant, h.userStore))).Methods(http.MethodPatch)
 	mux.Handle("/kermesses/{id}/stand", errors.ErrorHandler(middleware.IsAuth(h.AddStand, h.userStore))).Methods(http.MethodPatch)
-	mux.Handle("/kermesses/{id}/end", errors.ErrorHandler(middleware.IsAuth(h.End, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPatch)
+	mux.Handle("/kermesses/{id}/stats", errors.ErrorHandler(middleware.IsAuth(h.GetStats, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/leaderboard", errors.ErrorHandler(middleware.IsAuth(h.GetLeaderboard, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/leaderboard/live", errors.ErrorHandler(middleware.IsAuth(h.LiveLeaderboard, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/banner", errors.ErrorHandler(middleware.IsAuth(h.UploadBanner, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/kermesses/{id}/map", errors.ErrorHandler(middleware.IsAuth(h.GetMap, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/map", errors.ErrorHandler(middleware.IsAuth(h.UploadMap, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/kermesses/{id}/stands/{standId}/position", errors.ErrorHandler(middleware.IsAuth(h.UpdateStandPosition, h.userStore))).Methods(http.MethodPut)
+	mux.Handle("/kermesses/{id}/commission", errors.ErrorHandler(middleware.IsAuth(h.UpdateCommission, h.userStore))).Methods(http.MethodPut)
+	mux.Handle("/kermesses/{id}/stands/{standId}/commission", errors.ErrorHandler(middleware.IsAuth(h.UpdateStandCommission, h.userStore))).Methods(http.MethodPut)
+	mux.Handle("/kermesses/{id}/treasury", errors.ErrorHandler(middleware.IsAuth(h.GetTreasury, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/statut", errors.ErrorHandler(middleware.IsAuth(h.UpdateStatut, h.userStore))).Methods(http.MethodPatch)
+	mux.Handle("/kermesses/{id}/invitations", errors.ErrorHandler(middleware.IsAuth(h.GetInvitations, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/invitations", errors.ErrorHandler(middleware.IsAuth(h.CreateInvitation, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/kermesses/{id}/invitations/{invitationId}", errors.ErrorHandler(middleware.IsAuth(h.DeleteInvitation, h.userStore))).Methods(http.MethodDelete)
+	mux.Handle("/kermesses/{id}/roster", errors.ErrorHandler(middleware.IsAuth(h.ImportRoster, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/kermesses/{id}/members", errors.ErrorHandler(middleware.IsAuth(h.GetMembers, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/kermesses/{id}/members", errors.ErrorHandler(middleware.IsAuth(h.SaveMember, h.userStore))).Methods(http.MethodPut)
+	mux.Handle("/kermesses/{id}/members/{userId}", errors.ErrorHandler(middleware.IsAuth(h.DeleteMember, h.userStore))).Methods(http.MethodDelete)
+	mux.Handle("/kermesses/join", errors.ErrorHandler(middleware.IsAuth(h.Join, h.userStore, types.UserRoleParent))).Methods(http.MethodPost)
+	mux.Handle("/kermesses/{id}", errors.ErrorHandler(middleware.IsAuth(h.Delete, h.userStore))).Methods(http.MethodDelete)
+	mux.Handle("/kermesses/{id}/end", errors.ErrorHandler(middleware.IsAuth(h.End, h.userStore))).Methods(http.MethodPatch)
 }
 
 func (h *KermesseHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
-	kermesses, err := h.service.GetAll(r.Context())
+	kermesses, err := h.service.GetAll(r.Context(), utils.GetQueryParams(r))
 	if err != nil {
 		return err
 	}
@@ -233,7 +262,624 @@ func (h *KermesseHandler) End(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
-	if err := h.service.End(r.Context(), id); err != nil {
+	report, err := h.service.End(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, report); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) UploadBanner(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	data, err := readImage(w, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.UploadBanner(r.Context(), id, data); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) GetMap(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	kermesseMap, err := h.service.GetMap(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, kermesseMap); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) UploadMap(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	data, err := readImage(w, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.UploadMap(r.Context(), id, data); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) UpdateStandPosition(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	standId, err := strconv.Atoi(vars["standId"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.UpdateStandPosition(r.Context(), id, standId, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) UpdateCommission(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.UpdateCommission(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) UpdateStandCommission(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	standId, err := strconv.Atoi(vars["standId"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.UpdateStandCommission(r.Context(), id, standId, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) GetTreasury(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	treasury, err := h.service.GetTreasury(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, treasury); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) GetStats(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	stats, err := h.service.GetStats(r.Context(), id, utils.GetQueryParams(r))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, stats); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) UpdateStatut(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.UpdateStatut(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) GetInvitations(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	invitations, err := h.service.GetInvitations(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, invitations); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	invitation, err := h.service.CreateInvitation(r.Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, invitation); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) DeleteInvitation(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	invitationId, err := strconv.Atoi(vars["invitationId"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.DeleteInvitation(r.Context(), id, invitationId); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) Join(w http.ResponseWriter, r *http.Request) error {
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Join(r.Context(), input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) ImportRoster(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	data, err := readFile(w, r, kermesse.MaxRosterSize)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.service.ImportRoster(r.Context(), id, data, dryRun)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	if err := json.Write(w, status, report); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) GetMembers(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	members, err := h.service.GetMembers(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, members); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) SaveMember(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.SaveMember(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) DeleteMember(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	userId, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.DeleteMember(r.Context(), id, userId); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) GetTemplates(w http.ResponseWriter, r *http.Request) error {
+	kermesses, err := h.service.GetTemplates(r.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, kermesses); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) Clone(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	clone, err := h.service.Clone(r.Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, clone); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) SaveTemplate(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	clone, err := h.service.SaveTemplate(r.Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, clone); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *KermesseHandler) Delete(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
 		return err
 	}
 
@@ -246,3 +892,90 @@ func (h *KermesseHandler) End(w http.ResponseWriter, r *http.Request) error {
 
 	return nil
 }
+
+func (h *KermesseHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	leaderboard, err := h.service.GetLeaderboard(r.Context(), id, utils.GetQueryParams(r))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, leaderboard); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// Flux SSE du classement pour le grand écran : un événement "leaderboard" à chaque changement.
+func (h *KermesseHandler) LiveLeaderboard(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	params := utils.GetQueryParams(r)
+
+	// Les erreurs d'accès ou de paramètres sont renvoyées avant d'ouvrir le flux
+	leaderboard, err := h.service.GetLeaderboard(r.Context(), id, params)
+	if err != nil {
+		return err
+	}
+	stream, err := sse.NewStream(w)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	interval := utils.GetEnvInt("LEADERBOARD_REFRESH_INTERVAL", 5)
+	if interval <= 0 {
+		interval = 5
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		entries, err := encodingJson.Marshal(leaderboard.Entries)
+		if err != nil {
+			return nil
+		}
+		if !bytes.Equal(entries, last) {
+			data, err := encodingJson.Marshal(leaderboard)
+			if err != nil || stream.Send("leaderboard", data) != nil {
+				return nil
+			}
+			last = entries
+		} else if stream.Ping() != nil {
+			return nil
+		}
+
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		leaderboard, err = h.service.GetLeaderboard(r.Context(), id, params)
+		if err != nil {
+			log.Printf("Classement de la kermesse %d : %v", id, err)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/chall-goflutter-api/api/middleware"
+	"github.com/chall-goflutter-api/internal/notification"
+	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/json"
+	"github.com/chall-goflutter-api/pkg/utils"
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	service   notification.NotificationService
+	userStore user.UserStore
+}
+
+func NewNotificationHandler(service notification.NotificationService, userStore user.UserStore) *NotificationHandler {
+	return &NotificationHandler{
+		service:   service,
+		userStore: userStore,
+	}
+}
+
+func (h *NotificationHandler) RegisterRoutes(mux *mux.Router) {
+	mux.Handle("/notifications", errors.ErrorHandler(middleware.IsAuth(h.GetAll, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/notifications/{id}/read", errors.ErrorHandler(middleware.IsAuth(h.MarkRead, h.userStore))).Methods(http.MethodPatch)
+}
+
+func (h *NotificationHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
+	notifications, err := h.service.GetAll(r.Context(), utils.GetQueryParams(r))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, notifications); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.MarkRead(r.Context(), id); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/json"
+	"github.com/gorilla/mux"
+)
+
+type OrganisationHandler struct {
+	service organisation.OrganisationService
+}
+
+func NewOrganisationHandler(service organisation.OrganisationService) *OrganisationHandler {
+	return &OrganisationHandler{
+		service: service,
+	}
+}
+
+func (h *OrganisationHandler) RegisterRoutes(mux *mux.Router) {
+	// Pas d'authentification : la liste sert au choix de l'école à l'inscription
+	mux.Handle("/organisations", errors.ErrorHandler(h.GetAll)).Methods(http.MethodGet)
+}
+
+func (h *OrganisationHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
+	organisations, err := h.service.GetAll(r.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, organisations); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/chall-goflutter-api/api/middleware"
+	"github.com/chall-goflutter-api/internal/payment"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/json"
+	"github.com/gorilla/mux"
+)
+
+type PaymentHandler struct {
+	service   payment.PaymentService
+	userStore user.UserStore
+}
+
+func NewPaymentHandler(service payment.PaymentService, userStore user.UserStore) *PaymentHandler {
+	return &PaymentHandler{
+		service:   service,
+		userStore: userStore,
+	}
+}
+
+func (h *PaymentHandler) RegisterRoutes(mux *mux.Router) {
+	mux.Handle("/stands/{id}/payment-requests", errors.ErrorHandler(middleware.IsAuth(h.Create, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPost)
+	mux.Handle("/payment-requests/confirm", errors.ErrorHandler(middleware.IsAuth(h.Confirm, h.userStore, types.UserRoleParent, types.UserRoleEnfant))).Methods(http.MethodPost)
+}
+
+func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	request, err := h.service.Create(r.Context(), id, input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, request); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *PaymentHandler) Confirm(w http.ResponseWriter, r *http.Request) error {
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Confirm(r.Context(), input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
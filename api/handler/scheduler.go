@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/chall-goflutter-api/api/middleware"
+	"github.com/chall-goflutter-api/internal/scheduler"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/json"
+	"github.com/chall-goflutter-api/pkg/utils"
+	"github.com/gorilla/mux"
+)
+
+type SchedulerHandler struct {
+	service   scheduler.SchedulerService
+	userStore user.UserStore
+}
+
+func NewSchedulerHandler(service scheduler.SchedulerService, userStore user.UserStore) *SchedulerHandler {
+	return &SchedulerHandler{
+		service:   service,
+		userStore: userStore,
+	}
+}
+
+func (h *SchedulerHandler) RegisterRoutes(mux *mux.Router) {
+	mux.Handle("/jobs/runs", errors.ErrorHandler(middleware.IsAuth(h.GetRuns, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodGet)
+}
+
+func (h *SchedulerHandler) GetRuns(w http.ResponseWriter, r *http.Request) error {
+	runs, err := h.service.GetRuns(r.Context(), utils.GetQueryParams(r))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, runs); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
@@ -28,10 +28,19 @@ func NewStandHandler(service stand.StandService, userStore user.UserStore) *Stan
 
 func (h *StandHandler) RegisterRoutes(mux *mux.Router) {
 	mux.Handle("/stands", errors.ErrorHandler(middleware.IsAuth(h.GetAll, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/stands/search", errors.ErrorHandler(middleware.IsAuth(h.Search, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/stands/actuel", errors.ErrorHandler(middleware.IsAuth(h.GetCurrent, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodGet)
 	mux.Handle("/stands/{id}", errors.ErrorHandler(middleware.IsAuth(h.Get, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/stands", errors.ErrorHandler(middleware.IsAuth(h.Create, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPost)
 	mux.Handle("/stands", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPatch)
+	mux.Handle("/stands/{id}", errors.ErrorHandler(middleware.IsAuth(h.Delete, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodDelete)
+	mux.Handle("/stands/{id}/stock", errors.ErrorHandler(middleware.IsAuth(h.UpdateStock, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPatch)
+	mux.Handle("/stands/{id}/promotions", errors.ErrorHandler(middleware.IsAuth(h.GetPromotions, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/stands/{id}/promotions", errors.ErrorHandler(middleware.IsAuth(h.CreatePromotion, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPost)
+	mux.Handle("/stands/{id}/promotions/{promotionId}", errors.ErrorHandler(middleware.IsAuth(h.DeletePromotion, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodDelete)
+	mux.Handle("/stands/{id}/image", errors.ErrorHandler(middleware.IsAuth(h.UploadImage, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPost)
+	mux.Handle("/stands/{id}/product-image", errors.ErrorHandler(middleware.IsAuth(h.UploadProductImage, h.userStore, types.UserRoleTeneurStand))).Methods(http.MethodPost)
+	mux.Handle("/stands/{id}/stock-history", errors.ErrorHandler(middleware.IsAuth(h.GetStockHistory, h.userStore))).Methods(http.MethodGet)
 }
 
 func (h *StandHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
@@ -50,6 +59,22 @@ func (h *StandHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func (h *StandHandler) Search(w http.ResponseWriter, r *http.Request) error {
+	results, err := h.service.Search(r.Context(), utils.GetQueryParams(r))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, results); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
 func (h *StandHandler) GetCurrent(w http.ResponseWriter, r *http.Request) error {
 	stand, err := h.service.GetCurrent(r.Context())
 	if err != nil {
@@ -136,3 +161,230 @@ func (h *StandHandler) Update(w http.ResponseWriter, r *http.Request) error {
 
 	return nil
 }
+
+func (h *StandHandler) UpdateStock(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.UpdateStock(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	movements, err := h.service.GetStockHistory(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, movements); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) GetPromotions(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	promotions, err := h.service.GetPromotions(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, promotions); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.CreatePromotion(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusCreated, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	promotionId, err := strconv.Atoi(vars["promotionId"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.DeletePromotion(r.Context(), id, promotionId); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) UploadImage(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	data, err := readImage(w, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.UploadImage(r.Context(), id, data); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	data, err := readImage(w, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.UploadProductImage(r.Context(), id, data); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *StandHandler) Delete(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/chall-goflutter-api/api/middleware"
 	"github.com/chall-goflutter-api/internal/tombola"
-	"github.com/chall-goflutter-api/internal/types"
 	"github.com/chall-goflutter-api/internal/user"
 	"github.com/chall-goflutter-api/pkg/errors"
 	"github.com/chall-goflutter-api/pkg/json"
@@ -29,9 +28,13 @@ func NewTombolaHandler(service tombola.TombolaService, userStore user.UserStore)
 func (h *TombolaHandler) RegisterRoutes(mux *mux.Router) {
 	mux.Handle("/tombolas", errors.ErrorHandler(middleware.IsAuth(h.GetAll, h.userStore))).Methods(http.MethodGet)
 	mux.Handle("/tombolas/{id}", errors.ErrorHandler(middleware.IsAuth(h.Get, h.userStore))).Methods(http.MethodGet)
-	mux.Handle("/tombolas", errors.ErrorHandler(middleware.IsAuth(h.Create, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPost)
-	mux.Handle("/tombolas/{id}", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPatch)
-	mux.Handle("/tombolas/{id}/end", errors.ErrorHandler(middleware.IsAuth(h.End, h.userStore, types.UserRoleOrganisateur))).Methods(http.MethodPatch)
+	mux.Handle("/tombolas", errors.ErrorHandler(middleware.IsAuth(h.Create, h.userStore))).Methods(http.MethodPost)
+	mux.Handle("/tombolas/{id}", errors.ErrorHandler(middleware.IsAuth(h.Update, h.userStore))).Methods(http.MethodPatch)
+	mux.Handle("/tombolas/{id}", errors.ErrorHandler(middleware.IsAuth(h.Delete, h.userStore))).Methods(http.MethodDelete)
+	mux.Handle("/tombolas/{id}/end", errors.ErrorHandler(middleware.IsAuth(h.End, h.userStore))).Methods(http.MethodPatch)
+	mux.Handle("/tombolas/{id}/commitment", errors.ErrorHandler(middleware.IsAuth(h.Commit, h.userStore))).Methods(http.MethodPut)
+	mux.Handle("/tombolas/{id}/audit", errors.ErrorHandler(middleware.IsAuth(h.GetAudit, h.userStore))).Methods(http.MethodGet)
+	mux.Handle("/tombolas/{id}/cancel", errors.ErrorHandler(middleware.IsAuth(h.Cancel, h.userStore))).Methods(http.MethodPatch)
 }
 
 func (h *TombolaHandler) GetAll(w http.ResponseWriter, r *http.Request) error {
@@ -140,7 +143,120 @@ func (h *TombolaHandler) End(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
-	if err := h.service.End(r.Context(), id); err != nil {
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.End(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *TombolaHandler) Commit(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Commit(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *TombolaHandler) GetAudit(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	audit, err := h.service.GetAudit(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusOK, audit); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *TombolaHandler) Cancel(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Cancel(r.Context(), id); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *TombolaHandler) Delete(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
 		return err
 	}
 
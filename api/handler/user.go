@@ -31,7 +31,10 @@ func (h *UserHandler) RegisterRoutes(mux *mux.Router) {
 	mux.Handle("/users/{id}", errors.ErrorHandler(middleware.IsAuth(h.Get, h.store))).Methods(http.MethodGet)
 	mux.Handle("/users/invite", errors.ErrorHandler(middleware.IsAuth(h.Invite, h.store, types.UserRoleParent))).Methods(http.MethodPost)
 	mux.Handle("/users/distribute", errors.ErrorHandler(middleware.IsAuth(h.Distribute, h.store, types.UserRoleParent))).Methods(http.MethodPatch)
+	mux.Handle("/users/{id}", errors.ErrorHandler(middleware.IsAuth(h.Delete, h.store))).Methods(http.MethodDelete)
+	mux.Handle("/users/{id}/class", errors.ErrorHandler(middleware.IsAuth(h.UpdateClass, h.store, types.UserRoleParent))).Methods(http.MethodPatch)
 	mux.Handle("/users/{id}/password", errors.ErrorHandler(middleware.IsAuth(h.UpdatePassword, h.store))).Methods(http.MethodPatch)
+	mux.Handle("/users/password", errors.ErrorHandler(h.SetPassword)).Methods(http.MethodPost)
 	mux.Handle("/register", errors.ErrorHandler(h.Register)).Methods(http.MethodPost)
 	mux.Handle("/login", errors.ErrorHandler(h.Login)).Methods(http.MethodPost)
 	mux.Handle("/me", errors.ErrorHandler(middleware.IsAuth(h.GetMe, h.store))).Methods(http.MethodGet)
@@ -172,6 +175,29 @@ func (h *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) err
 	return nil
 }
 
+func (h *UserHandler) SetPassword(w http.ResponseWriter, r *http.Request) error {
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	if err := h.service.SetPassword(r.Context(), input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) error {
 	var input map[string]interface{}
 	if err := json.Parse(r, &input); err != nil {
@@ -234,3 +260,59 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) error {
 
 	return nil
 }
+
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (h *UserHandler) UpdateClass(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	var input map[string]interface{}
+	if err := json.Parse(r, &input); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	if err := h.service.UpdateClass(r.Context(), id, input); err != nil {
+		return err
+	}
+
+	if err := json.Write(w, http.StatusAccepted, nil); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
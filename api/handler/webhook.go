@@ -9,12 +9,16 @@ import (
 	"os"
 	"strconv"
 
-	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/internal/budget"
 	"github.com/stripe/stripe-go"
 	"github.com/stripe/stripe-go/webhook"
 )
 
-func HandleWebhook(userService user.UserService) http.HandlerFunc {
+// Enregistre les achats de jetons confirmés par Stripe. L'API ne crée pas les sessions
+// de paiement : celui qui les crée doit renseigner dans leurs metadata "user_id",
+// "jetons" et "kermesse_id", sans quoi le paiement n'apparaît dans le compte de
+// résultat d'aucune kermesse.
+func HandleWebhook(budgetService budget.BudgetService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const MaxBodyBytes = int64(65536)
 		r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
@@ -60,15 +64,44 @@ func HandleWebhook(userService user.UserService) http.HandlerFunc {
 				return
 			}
 
+			// Montant payé en centimes, absent de la structure de cette version du SDK
+			var amount struct {
+				AmountTotal int `json:"amount_total"`
+			}
+			if err := json.Unmarshal(event.Data.Raw, &amount); err != nil {
+				http.Error(w, "Webhook Error", http.StatusBadRequest)
+				return
+			}
+
+			// La kermesse est optionnelle : sans elle, l'achat ne compte dans aucun budget
+			var kermesseId interface{}
+			if kermesseIdStr, ok := session.Metadata["kermesse_id"]; ok {
+				id, err := strconv.Atoi(kermesseIdStr)
+				if err != nil {
+					http.Error(w, "Invalid kermesse id", http.StatusBadRequest)
+					return
+				}
+				kermesseId = id
+			}
+
 			log.Printf("user ID: %v\n", userId)
 			log.Printf("jetons: %v\n", credit)
 
-			err = userService.UpdateJetons(userId, credit)
-			log.Printf("ERROR: %v\n", err)
+			created, err := budgetService.RecordStripePayment(map[string]interface{}{
+				"kermesse_id":       kermesseId,
+				"user_id":           userId,
+				"amount":            amount.AmountTotal,
+				"jetons":            credit,
+				"stripe_session_id": session.ID,
+			})
 			if err != nil {
+				log.Printf("ERROR: %v\n", err)
 				http.Error(w, "Error updating user credit", http.StatusInternalServerError)
 				return
 			}
+			if !created {
+				log.Printf("Session %s déjà traitée\n", session.ID)
+			}
 		} else {
 			fmt.Printf("Unhandled event type: %s\n", event.Type)
 		}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
 	goErrors "errors"
 	"net/http"
 	"os"
@@ -39,9 +40,19 @@ func IsAuth(handlerFunc errors.ErrorHandler, store user.UserStore, roles ...stri
 			}
 		}
 
+		// Le compte a pu être supprimé depuis la délivrance du jeton
 		user, err := store.FindById(userId)
 		if err != nil {
-			return err
+			if goErrors.Is(err, sql.ErrNoRows) {
+				return errors.CustomError{
+					Key: errors.Unauthorized,
+					Err: goErrors.New("invalid token"),
+				}
+			}
+			return errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
 		}
 
 		if len(roles) > 0 {
@@ -60,9 +71,21 @@ func IsAuth(handlerFunc errors.ErrorHandler, store user.UserStore, roles ...stri
 			}
 		}
 
+		// Les ressources d'une autre école sont traitées comme inexistantes
+		owners, _ := r.Context().Value(types.ResourceOwnersKey).([]int)
+		for _, owner := range owners {
+			if owner != user.OrganisationId {
+				return errors.CustomError{
+					Key: errors.NotFound,
+					Err: goErrors.New("Ressource non trouvée"),
+				}
+			}
+		}
+
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, types.UserIDKey, user.Id)
 		ctx = context.WithValue(ctx, types.UserRoleKey, user.Role)
+		ctx = context.WithValue(ctx, types.OrganisationIDKey, user.OrganisationId)
 		r = r.WithContext(ctx)
 
 		return handlerFunc(w, r)
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/gorilla/mux"
+)
+
+// Résout l'école propriétaire des ressources désignées dans l'URL.
+// IsAuth la compare ensuite à celle de l'utilisateur connecté, pour toutes les routes authentifiées.
+func Tenant(store organisation.OrganisationStore) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return errors.ErrorHandler(func(w http.ResponseWriter, r *http.Request) error {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return nil
+			}
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return nil
+			}
+			segment, _, _ := strings.Cut(strings.TrimPrefix(template, "/"), "/")
+			resources := organisation.RouteResources[segment]
+
+			owners := []int{}
+			for name, value := range mux.Vars(r) {
+				resource, ok := resources[name]
+				if !ok {
+					continue
+				}
+				id, err := strconv.Atoi(value)
+				if err != nil {
+					continue
+				}
+				// Une ressource inexistante est signalée plus loin par le service
+				owner, err := store.FindOwner(resource, id)
+				if goErrors.Is(err, sql.ErrNoRows) {
+					continue
+				}
+				if err != nil {
+					return errors.CustomError{
+						Key: errors.InternalServerError,
+						Err: err,
+					}
+				}
+				owners = append(owners, owner)
+			}
+
+			ctx := context.WithValue(r.Context(), types.ResourceOwnersKey, owners)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+	}
+}
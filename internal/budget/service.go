@@ -0,0 +1,345 @@
+package budget
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/media"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/storage"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+const (
+	MaxReceiptSize = 5 << 20 // 5 Mo
+	maxLabelLength = 255
+	// Prix d'un jeton en centimes, sauf JETON_PRICE_CENTS
+	defaultJetonPrice = 100
+)
+
+type BudgetService interface {
+	GetExpenses(ctx context.Context, kermesseId int) ([]types.Expense, error)
+	CreateExpense(ctx context.Context, kermesseId int, input map[string]interface{}) (types.Expense, error)
+	UploadReceipt(ctx context.Context, id int, data []byte) error
+	DeleteExpense(ctx context.Context, id int) error
+	GetPayments(ctx context.Context, kermesseId int) ([]types.Payment, error)
+	CreateCashPayment(ctx context.Context, kermesseId int, input map[string]interface{}) error
+	RecordStripePayment(input map[string]interface{}) (bool, error)
+	GetReport(ctx context.Context, kermesseId int) (types.BudgetReport, error)
+}
+
+type Service struct {
+	store         BudgetStore
+	kermesseStore kermesse.KermesseStore
+	files         storage.Storage
+}
+
+func NewService(store BudgetStore, kermesseStore kermesse.KermesseStore, files storage.Storage) *Service {
+	return &Service{
+		store:         store,
+		kermesseStore: kermesseStore,
+		files:         files,
+	}
+}
+
+func (s *Service) GetExpenses(ctx context.Context, kermesseId int) ([]types.Expense, error) {
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionManage); err != nil {
+		return nil, err
+	}
+
+	expenses, err := s.store.FindExpenses(kermesseId)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	for i := range expenses {
+		expenses[i].ReceiptUrl = media.URL(s.files, expenses[i].ReceiptKey)
+	}
+
+	return expenses, nil
+}
+
+func (s *Service) CreateExpense(ctx context.Context, kermesseId int, input map[string]interface{}) (types.Expense, error) {
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionManage)
+	if err != nil {
+		return types.Expense{}, err
+	}
+	if kermesse.IsTemplate {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Un modèle ne peut pas avoir de dépenses"),
+		}
+	}
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	category, ok := input["category"].(string)
+	if !ok || !types.IsExpenseCategory(category) {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Catégorie de dépense invalide"),
+		}
+	}
+	label, ok := input["label"].(string)
+	if !ok || label == "" || len([]rune(label)) > maxLabelLength {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: fmt.Errorf("label doit contenir entre 1 et %d caractères", maxLabelLength),
+		}
+	}
+	amount, err := utils.GetIntFromMap(input, "amount")
+	if err != nil {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if amount <= 0 {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le montant doit être positif"),
+		}
+	}
+	spentAt, err := utils.GetTimeFromMap(input, "spent_at")
+	if err != nil {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if spentAt != nil && spentAt.After(time.Now()) {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("spent_at ne peut pas être dans le futur"),
+		}
+	}
+
+	expense, err := s.store.CreateExpense(map[string]interface{}{
+		"kermesse_id": kermesseId,
+		"user_id":     userId,
+		"category":    category,
+		"label":       label,
+		"amount":      amount,
+		"spent_at":    spentAt,
+	})
+	if err != nil {
+		return types.Expense{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return expense, nil
+}
+
+func (s *Service) UploadReceipt(ctx context.Context, id int, data []byte) error {
+	expense, err := s.findExpense(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	key, err := media.SaveDocument(s.files, fmt.Sprintf("kermesses/%d/receipts", expense.KermesseId), data)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdateReceipt(id, key); err != nil {
+		media.Delete(s.files, &key)
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	media.Delete(s.files, expense.ReceiptKey)
+
+	return nil
+}
+
+func (s *Service) DeleteExpense(ctx context.Context, id int) error {
+	expense, err := s.findExpense(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.DeleteExpense(id); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	media.Delete(s.files, expense.ReceiptKey)
+
+	return nil
+}
+
+func (s *Service) GetPayments(ctx context.Context, kermesseId int) ([]types.Payment, error) {
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionManage); err != nil {
+		return nil, err
+	}
+
+	payments, err := s.store.FindPayments(kermesseId)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return payments, nil
+}
+
+// Encaissement en espèces à la caisse de la kermesse. Les jetons achetés sont crédités
+// au participant indiqué, sinon l'entrée ne compte que dans les recettes.
+func (s *Service) CreateCashPayment(ctx context.Context, kermesseId int, input map[string]interface{}) error {
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionCash)
+	if err != nil {
+		return err
+	}
+	if kermesse.IsTemplate {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Un modèle ne peut pas avoir de recettes"),
+		}
+	}
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	amount, err := utils.GetIntFromMap(input, "amount")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if amount <= 0 {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le montant doit être positif"),
+		}
+	}
+	payment := map[string]interface{}{
+		"kermesse_id":       kermesseId,
+		"user_id":           nil,
+		"recorded_by":       userId,
+		"source":            types.PaymentSourceCash,
+		"amount":            amount,
+		"jetons":            0,
+		"stripe_session_id": nil,
+	}
+	if input["user_id"] != nil {
+		payerId, err := utils.GetIntFromMap(input, "user_id")
+		if err != nil {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		// Les jetons sont déduits du montant encaissé au prix du jeton, le caissier
+		// ne peut pas en créditer plus que ce qui a été payé
+		price := utils.GetEnvInt("JETON_PRICE_CENTS", defaultJetonPrice)
+		if price <= 0 {
+			price = defaultJetonPrice
+		}
+		if amount%price != 0 {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: fmt.Errorf("Le montant doit être un multiple du prix du jeton (%d centimes)", price),
+			}
+		}
+		jetons := amount / price
+		if input["jetons"] != nil {
+			expected, err := utils.GetIntFromMap(input, "jetons")
+			if err != nil || expected != jetons {
+				return errors.CustomError{
+					Key: errors.BadRequest,
+					Err: fmt.Errorf("Le montant correspond à %d jetons", jetons),
+				}
+			}
+		}
+		isParticipant, err := s.kermesseStore.IsParticipant(kermesseId, payerId)
+		if err != nil {
+			return errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !isParticipant {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("L'utilisateur ne participe pas à la kermesse"),
+			}
+		}
+		payment["user_id"] = payerId
+		payment["jetons"] = jetons
+	}
+
+	if _, err := s.store.CreatePayment(payment); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// Paiement Stripe confirmé par le webhook. Renvoie false si la session était déjà enregistrée.
+func (s *Service) RecordStripePayment(input map[string]interface{}) (bool, error) {
+	input["source"] = types.PaymentSourceStripe
+	input["recorded_by"] = nil
+
+	return s.store.CreatePayment(input)
+}
+
+func (s *Service) GetReport(ctx context.Context, kermesseId int) (types.BudgetReport, error) {
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionManage); err != nil {
+		return types.BudgetReport{}, err
+	}
+
+	report, err := s.store.Report(kermesseId)
+	if err != nil {
+		return types.BudgetReport{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return report, nil
+}
+
+func (s *Service) findExpense(ctx context.Context, id int) (types.Expense, error) {
+	expense, err := s.store.FindExpenseById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return expense, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return expense, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, expense.KermesseId, types.KermessePermissionManage); err != nil {
+		return expense, err
+	}
+
+	return expense, nil
+}
@@ -0,0 +1,149 @@
+package budget
+
+import (
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type BudgetStore interface {
+	FindExpenses(kermesseId int) ([]types.Expense, error)
+	FindExpenseById(id int) (types.Expense, error)
+	CreateExpense(input map[string]interface{}) (types.Expense, error)
+	UpdateReceipt(id int, key string) error
+	DeleteExpense(id int) error
+	FindPayments(kermesseId int) ([]types.Payment, error)
+	CreatePayment(input map[string]interface{}) (bool, error)
+	Report(kermesseId int) (types.BudgetReport, error)
+}
+
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const (
+	queryFindExpenses     = "SELECT * FROM expenses WHERE kermesse_id=$1 ORDER BY spent_at DESC, id DESC"
+	queryFindExpenseById  = "SELECT * FROM expenses WHERE id=$1"
+	queryCreateExpense    = "INSERT INTO expenses (kermesse_id, user_id, category, label, amount, spent_at) VALUES ($1, $2, $3, $4, $5, COALESCE($6, CURRENT_DATE)) RETURNING *"
+	queryUpdateReceipt    = "UPDATE expenses SET receipt_key=$1 WHERE id=$2"
+	queryDeleteExpense    = "DELETE FROM expenses WHERE id=$1"
+	queryFindPayments     = "SELECT * FROM payments WHERE kermesse_id=$1 ORDER BY created_at DESC"
+	queryCreatePayment    = "INSERT INTO payments (kermesse_id, user_id, recorded_by, source, amount, jetons, stripe_session_id) VALUES ((SELECT id FROM kermesses WHERE id=$1), $2, $3, $4, $5, $6, $7) ON CONFLICT (stripe_session_id) DO NOTHING"
+	queryCreditJetons     = "UPDATE users SET jetons=jetons+$1 WHERE id=$2"
+	queryExpensesCategory = "SELECT category, SUM(amount) AS amount FROM expenses WHERE kermesse_id=$1 GROUP BY category"
+)
+
+func (s *Store) FindExpenses(kermesseId int) ([]types.Expense, error) {
+	expenses := []types.Expense{}
+	err := s.db.Select(&expenses, queryFindExpenses, kermesseId)
+
+	return expenses, err
+}
+
+func (s *Store) FindExpenseById(id int) (types.Expense, error) {
+	expense := types.Expense{}
+	err := s.db.Get(&expense, queryFindExpenseById, id)
+
+	return expense, err
+}
+
+func (s *Store) CreateExpense(input map[string]interface{}) (types.Expense, error) {
+	expense := types.Expense{}
+	err := s.db.Get(&expense, queryCreateExpense, input["kermesse_id"], input["user_id"], input["category"], input["label"], input["amount"], input["spent_at"])
+
+	return expense, err
+}
+
+func (s *Store) UpdateReceipt(id int, key string) error {
+	_, err := s.db.Exec(queryUpdateReceipt, key, id)
+
+	return err
+}
+
+func (s *Store) DeleteExpense(id int) error {
+	_, err := s.db.Exec(queryDeleteExpense, id)
+
+	return err
+}
+
+func (s *Store) FindPayments(kermesseId int) ([]types.Payment, error) {
+	payments := []types.Payment{}
+	err := s.db.Select(&payments, queryFindPayments, kermesseId)
+
+	return payments, err
+}
+
+// Enregistre le paiement et crédite les jetons achetés dans la même transaction.
+// Renvoie false si la session Stripe a déjà été enregistrée (webhook rejoué).
+func (s *Store) CreatePayment(input map[string]interface{}) (created bool, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	result, err := tx.Exec(queryCreatePayment, input["kermesse_id"], input["user_id"], input["recorded_by"], input["source"], input["amount"], input["jetons"], input["stripe_session_id"])
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	if input["user_id"] != nil && input["jetons"] != 0 {
+		if _, err = tx.Exec(queryCreditJetons, input["jetons"], input["user_id"]); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Store) Report(kermesseId int) (types.BudgetReport, error) {
+	report := types.BudgetReport{
+		KermesseId:         kermesseId,
+		ExpensesByCategory: map[string]int{},
+	}
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE source = $2), 0) AS stripe_income,
+			COALESCE(SUM(amount) FILTER (WHERE source = $3), 0) AS cash_income
+		FROM payments
+		WHERE kermesse_id = $1
+	`
+	err := s.db.Get(&report, query, kermesseId, types.PaymentSourceStripe, types.PaymentSourceCash)
+	if err != nil {
+		return report, err
+	}
+
+	categories := []struct {
+		Category string `db:"category"`
+		Amount   int    `db:"amount"`
+	}{}
+	if err := s.db.Select(&categories, queryExpensesCategory, kermesseId); err != nil {
+		return report, err
+	}
+	for _, category := range categories {
+		report.ExpensesByCategory[category.Category] = category.Amount
+		report.Expenses += category.Amount
+	}
+	report.Income = report.StripeIncome + report.CashIncome
+	report.Profit = report.Income - report.Expenses
+
+	return report, nil
+}
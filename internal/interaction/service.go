@@ -4,8 +4,13 @@ import (
 	"context"
 	"database/sql"
 	goErrors "errors"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/internal/shift"
 	"github.com/chall-goflutter-api/internal/stand"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/chall-goflutter-api/internal/user"
@@ -13,11 +18,18 @@ import (
 	"github.com/chall-goflutter-api/pkg/utils"
 )
 
+// Durée maximale, en minutes, entre l'enregistrement hors ligne et la synchronisation.
+const defaultOfflineSyncWindow = 120
+
 type InteractionService interface {
 	GetAll(ctx context.Context, params map[string]interface{}) ([]types.InteractionBasic, error)
 	Get(ctx context.Context, id int) (types.Interaction, error)
 	Create(ctx context.Context, input map[string]interface{}) error
+	CreateFromRequest(ctx context.Context, requestId int, input map[string]interface{}) error
 	Update(ctx context.Context, id int, input map[string]interface{}) error
+	Sync(ctx context.Context, standId int, items []map[string]interface{}) ([]types.InteractionSyncResult, error)
+	IssueOfflineToken(ctx context.Context, input map[string]interface{}) (types.OfflineToken, error)
+	Refund(ctx context.Context, id int) error
 }
 
 type Service struct {
@@ -25,14 +37,18 @@ type Service struct {
 	standStore    stand.StandStore
 	userStore     user.UserStore
 	kermesseStore kermesse.KermesseStore
+	shiftStore    shift.ShiftStore
+	tokenSecret   string
 }
 
-func NewService(store InteractionStore, standStore stand.StandStore, userStore user.UserStore, kermesseStore kermesse.KermesseStore) *Service {
+func NewService(store InteractionStore, standStore stand.StandStore, userStore user.UserStore, kermesseStore kermesse.KermesseStore, shiftStore shift.ShiftStore, tokenSecret string) *Service {
 	return &Service{
 		store:         store,
 		standStore:    standStore,
 		userStore:     userStore,
 		kermesseStore: kermesseStore,
+		shiftStore:    shiftStore,
+		tokenSecret:   tokenSecret,
 	}
 }
 
@@ -62,7 +78,17 @@ func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]
 		filters["teneur_stand_id"] = userId
 	}
 	if params["kermesse_id"] != nil {
-		filters["kermesse_id"] = params["kermesse_id"]
+		kermesseId, err := utils.GetIntFromParams(params, "kermesse_id")
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		filters["kermesse_id"] = kermesseId
+	}
+	if err := organisation.Scope(ctx, filters); err != nil {
+		return nil, err
 	}
 
 	interactions, err := s.store.FindAll(filters)
@@ -95,6 +121,36 @@ func (s *Service) Get(ctx context.Context, id int) (types.Interaction, error) {
 }
 
 func (s *Service) Create(ctx context.Context, input map[string]interface{}) error {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	// Seul le paiement d'une demande scannée consomme une demande, voir CreateFromRequest
+	delete(input, "payment_request_id")
+
+	return s.create(userId, input)
+}
+
+// Achat réglant la demande de paiement requestId, marquée utilisée dans la même transaction.
+func (s *Service) CreateFromRequest(ctx context.Context, requestId int, input map[string]interface{}) error {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	input["payment_request_id"] = requestId
+
+	return s.create(userId, input)
+}
+
+// Achat ou participation de l'utilisateur userId au stand input["stand_id"],
+// avec toutes les vérifications (participation, stock, capacité, jetons).
+func (s *Service) create(userId int, input map[string]interface{}) error {
 	standId, err := utils.GetIntFromMap(input, "stand_id")
 	if err != nil {
 		return errors.CustomError{
@@ -116,13 +172,6 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
-	userId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
-		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
-		}
-	}
 	user, err := s.userStore.FindById(userId)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -137,9 +186,19 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
+	kermesseId, err := utils.GetIntFromMap(input, "kermesse_id")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	// L'utilisateur et le stand doivent participer à cette kermesse ouverte,
+	// dont dépendent la commission et les promotions appliquées
 	canCreate, err := s.store.CanCreate(map[string]interface{}{
-		"user_id":  userId,
-		"stand_id": standId,
+		"user_id":     userId,
+		"stand_id":    standId,
+		"kermesse_id": kermesseId,
 	})
 	if err != nil {
 		return errors.CustomError{
@@ -154,92 +213,200 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
+	rate, err := s.kermesseStore.FindCommissionRate(kermesseId, standId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	// Promotions du stand applicables maintenant dans cette kermesse
+	promotions, err := s.standStore.FindActivePromotions(standId, kermesseId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
 	totalPrice := stand.Price
+	discount := 0
+	quantity := 1
+	var promotion *types.Promotion
 	if stand.Type == types.StandTypeVente {
-		quantity, err := utils.GetIntFromMap(input, "quantity")
+		quantity, err = utils.GetIntFromMap(input, "quantity")
 		if err != nil {
 			return errors.CustomError{
 				Key: errors.BadRequest,
 				Err: err,
 			}
 		}
-		// Check si le stand a assez de stock
-		if stand.Stock < quantity {
+		if quantity <= 0 {
 			return errors.CustomError{
 				Key: errors.BadRequest,
-				Err: goErrors.New("Pas assez de stock"),
+				Err: goErrors.New("Quantité invalide"),
 			}
 		}
+	}
+	totalPrice, promotion = types.BestPromotion(stand.Price, quantity, promotions)
+	discount = stand.Price*quantity - totalPrice
+	if err := checkExpectedPrice(input, totalPrice); err != nil {
+		return err
+	}
+	if maxJetons, ok := input["max_jetons"].(int); ok && totalPrice > maxJetons {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le montant dépasse celui autorisé par l'enfant"),
+		}
+	}
+
+	if stand.Type == types.StandTypeVente {
+		input["type"] = types.InteractionTypeTransaction
+	} else {
+		input["type"] = types.InteractionTypeActivite
+	}
+	input["user_id"] = user.Id
+	input["owner_id"] = stand.UserId
+	input["stand_id"] = standId
+	input["kermesse_id"] = kermesseId
+	input["jetons"] = totalPrice
+	input["discount"] = discount
+	input["quantity"] = quantity
+	// Le propriétaire du stand reçoit la vente, moins la commission de l'école
+	input["commission"] = types.Commission(totalPrice, rate)
+	input["promotion_id"] = nil
+	if promotion != nil {
+		input["promotion_id"] = promotion.Id
+	}
 
-		// Check si l'utilisateur a assez de jetons
-		totalPrice = stand.Price * quantity
-		if user.Jetons < totalPrice {
+	// Stock, capacité et jetons sont revérifiés dans la transaction, stand verrouillé
+	if _, err := s.store.Create(input); err != nil {
+		if goErrors.Is(err, ErrDuplicate) {
 			return errors.CustomError{
-				Key: errors.BadRequest,
-				Err: goErrors.New("Pas assez de jetons"),
+				Key: errors.Conflict,
+				Err: err,
 			}
 		}
-
-		// mettre à jour le stock du stand et les jetons de l'utilisateur
-		err = s.standStore.UpdateStock(standId, -quantity)
-		if err != nil {
+		if goErrors.Is(err, ErrOutOfStock) || goErrors.Is(err, ErrStandFull) || goErrors.Is(err, ErrNotEnoughJetons) || goErrors.Is(err, ErrRequestUsed) {
 			return errors.CustomError{
-				Key: errors.InternalServerError,
+				Key: errors.BadRequest,
 				Err: err,
 			}
 		}
-		err = s.userStore.UpdateJetons(userId, -totalPrice)
-		if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
-				Key: errors.InternalServerError,
+				Key: errors.NotFound,
 				Err: err,
 			}
 		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
 
-		// Ajouter les jetons au propriétaire du stand
-		err = s.userStore.UpdateJetons(stand.UserId, totalPrice)
-		if err != nil {
+	return nil
+}
+
+func (s *Service) Update(ctx context.Context, id int, input map[string]interface{}) error {
+	interaction, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
-				Key: errors.InternalServerError,
+				Key: errors.NotFound,
 				Err: err,
 			}
 		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
 
-	} else {
-		// mettre à jour les jetons de l'utilisateur
-		if user.Jetons < totalPrice {
-			return errors.CustomError{
-				Key: errors.BadRequest,
-				Err: goErrors.New("Pas assez de jetons"),
-			}
+	if interaction.Type != types.InteractionTypeActivite {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'interaction n'est pas une activité"),
 		}
-		err = s.userStore.UpdateJetons(userId, -totalPrice)
-		if err != nil {
+	}
+	if interaction.Statut == types.InteractionStatutEnded {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'interaction est déjà terminée"),
+		}
+	}
+
+	kermesse, err := s.kermesseStore.FindById(interaction.Kermesse.Id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
-				Key: errors.InternalServerError,
+				Key: errors.NotFound,
 				Err: err,
 			}
 		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if kermesse.Statut != types.KermesseStatutOpen {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse n'est pas ouverte"),
+		}
+	}
 
-		// Ajouter les jetons au propriétaire du stand
-		err = s.userStore.UpdateJetons(stand.UserId, totalPrice)
-		if err != nil {
+	stand, err := s.standStore.FindById(interaction.Stand.Id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
-				Key: errors.InternalServerError,
+				Key: errors.NotFound,
 				Err: err,
 			}
 		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
 	}
 
-	if stand.Type == types.StandTypeVente {
-		input["type"] = types.InteractionTypeTransaction
-	} else {
-		input["type"] = types.InteractionTypeActivite
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	canRecord, err := s.canRecord(stand, userId, time.Now())
+	if err != nil {
+		return err
+	}
+	if !canRecord {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	points, err := stand.Scoring.Points(input)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
 	}
-	input["user_id"] = user.Id
-	input["jetons"] = totalPrice
 
-	err = s.store.Create(input)
+	err = s.store.Update(id, map[string]interface{}{
+		"statut": types.InteractionStatutEnded,
+		"points": points,
+	})
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
@@ -250,7 +417,9 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 	return nil
 }
 
-func (s *Service) Update(ctx context.Context, id int, input map[string]interface{}) error {
+// Annule l'interaction : l'acheteur récupère ses jetons, repris au stand et à la trésorerie
+// dans les proportions de la vente, et le stock vendu revient au stand.
+func (s *Service) Refund(ctx context.Context, id int) error {
 	interaction, err := s.store.FindById(id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -264,15 +433,14 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 			Err: err,
 		}
 	}
-
-	if interaction.Type != types.InteractionTypeActivite {
+	if interaction.RefundedAt != nil {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("L'interaction n'est pas une activité"),
+			Err: goErrors.New("L'interaction est déjà remboursée"),
 		}
 	}
 
-	kermesse, err := s.kermesseStore.FindById(interaction.Kermesse.Id)
+	stand, err := s.standStore.FindById(interaction.Stand.Id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
@@ -285,14 +453,32 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 			Err: err,
 		}
 	}
-	if kermesse.Statut == types.KermesseStatutEnded {
+
+	// Le teneur du stand ou la caisse de la kermesse
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
 		return errors.CustomError{
-			Key: errors.BadRequest,
-			Err: goErrors.New("La kermesse est terminée"),
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		canCash, err := kermesse.Can(s.kermesseStore, interaction.Kermesse.Id, userId, types.KermessePermissionCash)
+		if err != nil {
+			return errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !canCash {
+			return errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interdit"),
+			}
 		}
 	}
 
-	stand, err := s.standStore.FindById(interaction.Stand.Id)
+	kermesse, err := s.kermesseStore.FindById(interaction.Kermesse.Id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
@@ -305,31 +491,213 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 			Err: err,
 		}
 	}
+	if kermesse.IsFinished() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+
+	refunded, err := s.store.Refund(id, map[string]interface{}{
+		"user_id":  userId,
+		"owner_id": stand.UserId,
+	})
+	if err != nil {
+		if goErrors.Is(err, ErrStandShort) || goErrors.Is(err, ErrTreasuryShort) {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !refunded {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'interaction est déjà remboursée"),
+		}
+	}
+
+	return nil
+}
+
+// Rejoue dans l'ordre les interactions enregistrées hors ligne par l'appareil d'un stand.
+// Chaque interaction porte l'autorisation de paiement présentée par l'enfant, dont le
+// client_uuid rend le rejeu idempotent : une autorisation déjà utilisée est signalée comme doublon.
+func (s *Service) Sync(ctx context.Context, standId int, items []map[string]interface{}) ([]types.InteractionSyncResult, error) {
+	stand, err := s.standStore.FindById(standId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
 
 	userId, ok := ctx.Value(types.UserIDKey).(int)
 	if !ok {
-		return errors.CustomError{
+		return nil, errors.CustomError{
 			Key: errors.Unauthorized,
 			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
 		}
 	}
+	// Un bénévole ne synchronise que les interactions enregistrées pendant ses créneaux
+	volunteerId := 0
 	if stand.UserId != userId {
-		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit"),
+		isVolunteer, err := s.shiftStore.IsVolunteer(standId, userId)
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !isVolunteer {
+			return nil, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interdit"),
+			}
 		}
+		volunteerId = userId
 	}
 
-	err = s.store.Update(id, map[string]interface{}{
-		"statut": types.InteractionStatutEnded,
-		"points": input["points"],
+	results := []types.InteractionSyncResult{}
+	for _, item := range items {
+		clientUuid, _ := item["client_uuid"].(string)
+		result := types.InteractionSyncResult{
+			ClientUuid: clientUuid,
+			Statut:     types.InteractionSyncAccepted,
+		}
+
+		// Une erreur sur une interaction la rejette sans interrompre le lot :
+		// sa transaction est annulée et elle pourra être renvoyée
+		claims, err := s.syncItem(stand, volunteerId, item)
+		if claims.ClientUuid != "" {
+			result.ClientUuid = claims.ClientUuid
+		}
+		if err != nil {
+			var customErr errors.CustomError
+			if goErrors.As(err, &customErr) && customErr.Key == errors.Conflict {
+				result.Statut = types.InteractionSyncDuplicate
+			} else if goErrors.As(err, &customErr) && customErr.Key != errors.InternalServerError {
+				result.Statut = types.InteractionSyncRejected
+				result.Reason = customErr.Error()
+			} else {
+				log.Printf("Error syncing interaction %s on stand %d: %v", result.ClientUuid, standId, err)
+				result.Statut = types.InteractionSyncRejected
+				result.Reason = "Erreur interne, l'interaction peut être renvoyée"
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (s *Service) syncItem(stand types.Stand, volunteerId int, item map[string]interface{}) (types.OfflineTokenClaims, error) {
+	token, ok := item["token"].(string)
+	if !ok {
+		return types.OfflineTokenClaims{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Autorisation de paiement manquante"),
+		}
+	}
+	claims, err := decodeOfflineToken(s.tokenSecret, token)
+	if err != nil {
+		return types.OfflineTokenClaims{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	createdAt, err := utils.GetTimeFromMap(item, "created_at")
+	if err != nil {
+		return claims, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if createdAt != nil && createdAt.After(time.Now()) {
+		return claims, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("created_at ne peut pas être dans le futur"),
+		}
+	}
+	// L'heure locale détermine le créneau du bénévole : elle ne peut remonter qu'à la durée
+	// d'une coupure réseau, sans quoi un ancien créneau suffirait à enregistrer à tout moment
+	window := utils.GetEnvInt("OFFLINE_SYNC_WINDOW_MINUTES", defaultOfflineSyncWindow)
+	if createdAt != nil && createdAt.Before(time.Now().Add(-time.Duration(window)*time.Minute)) {
+		return claims, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: fmt.Errorf("created_at ne peut pas remonter à plus de %d minutes", window),
+		}
+	}
+	at := time.Now()
+	if createdAt != nil {
+		at = *createdAt
+	}
+	if at.Unix() >= claims.ExpiresAt {
+		return claims, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'autorisation de paiement avait expiré"),
+		}
+	}
+
+	if volunteerId != 0 {
+		canRecord, err := s.canRecord(stand, volunteerId, at)
+		if err != nil {
+			return claims, err
+		}
+		if !canRecord {
+			return claims, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interaction enregistrée en dehors de votre créneau"),
+			}
+		}
+	}
+
+	return claims, s.create(claims.UserId, map[string]interface{}{
+		"stand_id":    float64(stand.Id),
+		"kermesse_id": float64(claims.KermesseId),
+		"quantity":    item["quantity"],
+		"client_uuid": claims.ClientUuid,
+		"created_at":  createdAt,
+		"max_jetons":  claims.MaxJetons,
 	})
+}
+
+// Le teneur du stand peut toujours enregistrer, un bénévole seulement pendant son créneau.
+func (s *Service) canRecord(stand types.Stand, userId int, at time.Time) (bool, error) {
+	if stand.UserId == userId {
+		return true, nil
+	}
+	onShift, err := s.shiftStore.IsOnShift(stand.Id, userId, at)
 	if err != nil {
-		return errors.CustomError{
+		return false, errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
 
+	return onShift, nil
+}
+
+// Refuse l'achat si le prix a changé depuis qu'il a été présenté (demande de paiement).
+func checkExpectedPrice(input map[string]interface{}, totalPrice int) error {
+	expected, ok := input["expected_jetons"].(int)
+	if ok && expected != totalPrice {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le prix a changé"),
+		}
+	}
+
 	return nil
 }
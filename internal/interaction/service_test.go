@@ -0,0 +1,112 @@
+package interaction
+
+import (
+	"context"
+	goErrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/stand"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+type fakeInteractionStore struct {
+	InteractionStore
+	err     error
+	created map[string]interface{}
+}
+
+func (f *fakeInteractionStore) CanCreate(input map[string]interface{}) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeInteractionStore) Create(input map[string]interface{}) (int, error) {
+	f.created = input
+	return 1, f.err
+}
+
+type fakeStandStore struct {
+	stand.StandStore
+}
+
+func (f *fakeStandStore) FindById(id int) (types.Stand, error) {
+	return types.Stand{Id: id, UserId: 2, Type: types.StandTypeActivite, Price: 3}, nil
+}
+
+func (f *fakeStandStore) FindActivePromotions(id int, kermesseId interface{}) ([]types.Promotion, error) {
+	return nil, nil
+}
+
+type fakeUserStore struct {
+	user.UserStore
+}
+
+func (f *fakeUserStore) FindById(id int) (types.User, error) {
+	return types.User{Id: id, Jetons: 10}, nil
+}
+
+type fakeKermesseStore struct {
+	kermesse.KermesseStore
+}
+
+func (f *fakeKermesseStore) FindCommissionRate(id int, standId int) (int, error) {
+	return 0, nil
+}
+
+func TestCreateStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"interaction déjà enregistrée", ErrDuplicate, http.StatusConflict},
+		{"pas assez de jetons", ErrNotEnoughJetons, http.StatusBadRequest},
+		{"demande de paiement déjà utilisée", ErrRequestUsed, http.StatusBadRequest},
+		{"erreur du store", goErrors.New("connexion perdue"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(&fakeInteractionStore{err: tt.err}, &fakeStandStore{}, &fakeUserStore{}, &fakeKermesseStore{}, nil, "secret")
+			ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+
+			err := service.Create(ctx, map[string]interface{}{
+				"stand_id":    float64(5),
+				"kermesse_id": float64(7),
+				"client_uuid": "déjà-vu",
+			})
+			var customErr errors.CustomError
+			if !goErrors.As(err, &customErr) {
+				t.Fatalf("erreur = %v, attendu une CustomError", err)
+			}
+			if customErr.StatusCode() != tt.want {
+				t.Errorf("statut = %d, attendu %d", customErr.StatusCode(), tt.want)
+			}
+		})
+	}
+}
+
+// Une demande de paiement n'est consommée que par CreateFromRequest, jamais par le corps d'un achat.
+func TestCreatePaymentRequest(t *testing.T) {
+	store := &fakeInteractionStore{}
+	service := NewService(store, &fakeStandStore{}, &fakeUserStore{}, &fakeKermesseStore{}, nil, "secret")
+	ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+
+	err := service.Create(ctx, map[string]interface{}{"stand_id": float64(5), "kermesse_id": float64(7), "payment_request_id": 9})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if store.created["payment_request_id"] != nil {
+		t.Errorf("payment_request_id = %v, attendu aucun", store.created["payment_request_id"])
+	}
+
+	err = service.CreateFromRequest(ctx, 9, map[string]interface{}{"stand_id": float64(5), "kermesse_id": float64(7)})
+	if err != nil {
+		t.Fatalf("CreateFromRequest: %v", err)
+	}
+	if store.created["payment_request_id"] != 9 {
+		t.Errorf("payment_request_id = %v, attendu 9", store.created["payment_request_id"])
+	}
+}
@@ -1,18 +1,35 @@
 package interaction
 
 import (
+	"database/sql"
+	goErrors "errors"
 	"fmt"
 
+	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/stand"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/jmoiron/sqlx"
 )
 
+// Refus des transactions d'achat et de remboursement.
+var (
+	ErrOutOfStock      = goErrors.New("Pas assez de stock")
+	ErrStandFull       = goErrors.New("Le stand est complet")
+	ErrNotEnoughJetons = goErrors.New("Pas assez de jetons")
+	ErrDuplicate       = goErrors.New("Interaction déjà synchronisée")
+	ErrRequestUsed     = goErrors.New("La demande de paiement a déjà été utilisée ou a expiré")
+	ErrStandShort      = goErrors.New("Le stand n'a plus assez de jetons pour rembourser")
+	ErrTreasuryShort   = goErrors.New("La trésorerie n'a plus assez de jetons pour rembourser")
+)
+
 type InteractionStore interface {
 	FindAll(filters map[string]interface{}) ([]types.InteractionBasic, error)
 	FindById(id int) (types.Interaction, error)
 	CanCreate(input map[string]interface{}) (bool, error)
-	Create(input map[string]interface{}) error
+	Create(input map[string]interface{}) (int, error)
 	Update(id int, input map[string]interface{}) error
+	Refund(id int, input map[string]interface{}) (bool, error)
+	EndExpired(defaultTimeout int) ([]types.InteractionClosed, error)
 }
 
 type Store struct {
@@ -26,8 +43,15 @@ func NewStore(db *sqlx.DB) *Store {
 }
 
 const (
-	queryCreateInteraction = "INSERT INTO interactions (user_id, kermesse_id, stand_id, type, jetons) VALUES ($1, $2, $3, $4, $5)"
+	queryCreateInteraction = "INSERT INTO interactions (user_id, kermesse_id, stand_id, type, jetons, promotion_id, discount, client_uuid, created_at, quantity, commission) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, CURRENT_TIMESTAMP), $10, $11) ON CONFLICT (client_uuid) DO NOTHING RETURNING id"
 	queryUpdateInteraction = "UPDATE interactions SET statut=$1, points=$2 WHERE id=$3"
+	queryRefundInteraction = "UPDATE interactions SET refunded_at=NOW(), statut=$1, points=0 WHERE id=$2 AND refunded_at IS NULL RETURNING user_id, kermesse_id, stand_id, type, jetons, commission, quantity"
+	queryLockTreasury      = "SELECT treasury_jetons FROM kermesses WHERE id=$1 FOR UPDATE"
+	queryUseRequest        = "UPDATE payment_requests SET used_at=NOW(), used_by=$1 WHERE id=$2 AND used_at IS NULL AND expires_at > NOW()"
+	queryLockStand         = "SELECT type, stock, capacity FROM stands WHERE id=$1 AND deleted_at IS NULL FOR UPDATE"
+	queryCountActivities   = "SELECT COUNT(*) FROM interactions WHERE stand_id=$1 AND type=$2 AND statut=$3 AND id <> $4"
+	queryDebitJetons       = "UPDATE users SET jetons=jetons-$1 WHERE id=$2 AND jetons >= $1"
+	queryCreditJetons      = "UPDATE users SET jetons=jetons+$1 WHERE id=$2"
 )
 
 func (s *Store) FindAll(filters map[string]interface{}) ([]types.InteractionBasic, error) {
@@ -39,6 +63,11 @@ func (s *Store) FindAll(filters map[string]interface{}) ([]types.InteractionBasi
 			i.statut AS statut,
 			i.jetons AS jetons,
 			i.points AS points,
+			i.promotion_id AS promotion_id,
+			i.discount AS discount,
+			i.quantity AS quantity,
+			i.commission AS commission,
+			i.refunded_at AS refunded_at,
 			i.created_at AS created_at,
 			u.id AS "user.id",
 			u.name AS "user.name",
@@ -52,22 +81,31 @@ func (s *Store) FindAll(filters map[string]interface{}) ([]types.InteractionBasi
 		FROM interactions i
 		JOIN users u ON i.user_id = u.id
 		JOIN stands s ON i.stand_id = s.id
-		WHERE 1=1
+		JOIN kermesses k ON i.kermesse_id = k.id
+		WHERE k.statut <> 'ARCHIVED' AND k.deleted_at IS NULL
 	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filters["organisation_id"] != nil {
+		query += fmt.Sprintf(" AND k.organisation_id = %s", arg(filters["organisation_id"]))
+	}
 	if filters["kermesse_id"] != nil {
-		query += fmt.Sprintf(" AND i.kermesse_id = %v", filters["kermesse_id"])
+		query += fmt.Sprintf(" AND i.kermesse_id = %s", arg(filters["kermesse_id"]))
 	}
 	if filters["parent_id"] != nil {
-		query += fmt.Sprintf(" AND (u.id = %v OR u.parent_id = %v)", filters["parent_id"], filters["parent_id"])
+		query += fmt.Sprintf(" AND (u.id = %[1]s OR u.parent_id = %[1]s)", arg(filters["parent_id"]))
 	}
 	if filters["enfant_id"] != nil {
-		query += fmt.Sprintf(" AND u.id = %v", filters["enfant_id"])
+		query += fmt.Sprintf(" AND u.id = %s", arg(filters["enfant_id"]))
 	}
 	if filters["teneur_stand_id"] != nil {
-		query += fmt.Sprintf(" AND s.user_id = %v", filters["teneur_stand_id"])
+		query += fmt.Sprintf(" AND s.user_id = %s", arg(filters["teneur_stand_id"]))
 	}
 	query += " ORDER BY i.created_at DESC"
-	err := s.db.Select(&interactions, query)
+	err := s.db.Select(&interactions, query, args...)
 
 	return interactions, err
 }
@@ -81,6 +119,11 @@ func (s *Store) FindById(id int) (types.Interaction, error) {
 			i.statut AS statut,
 			i.jetons AS jetons,
 			i.points AS points,
+			i.promotion_id AS promotion_id,
+			i.discount AS discount,
+			i.quantity AS quantity,
+			i.commission AS commission,
+			i.refunded_at AS refunded_at,
 			i.created_at AS created_at,
 			u.id AS "user.id",
 			u.name AS "user.name",
@@ -114,18 +157,194 @@ func (s *Store) CanCreate(input map[string]interface{}) (bool, error) {
 			FROM kermesses_users ku
   		JOIN kermesses_stands ks ON ku.kermesse_id = ks.kermesse_id
 			JOIN kermesses k ON ku.kermesse_id = k.id
-  		WHERE ku.user_id = $1 AND ks.stand_id = $2 AND k.statut = $3
+  		WHERE ku.user_id = $1 AND ks.stand_id = $2 AND k.statut = $3 AND k.id = $4
 		) AS is_associated
  	`
-	err := s.db.QueryRow(query, input["user_id"], input["stand_id"], types.KermesseStatutStarted).Scan(&isAssociated)
+	err := s.db.QueryRow(query, input["user_id"], input["stand_id"], types.KermesseStatutOpen, input["kermesse_id"]).Scan(&isAssociated)
 
 	return isAssociated, err
 }
 
-func (s *Store) Create(input map[string]interface{}) error {
-	_, err := s.db.Exec(queryCreateInteraction, input["user_id"], input["kermesse_id"], input["stand_id"], input["type"], input["jetons"])
+// Enregistre l'achat ou la participation en une seule transaction : le client_uuid est
+// réservé en premier (ErrDuplicate s'il est déjà connu), la ligne du stand reste verrouillée
+// le temps de vérifier le stock ou la capacité, puis l'acheteur est débité, le teneur
+// du stand (owner_id) crédité de sa part et la commission versée à la trésorerie.
+func (s *Store) Create(input map[string]interface{}) (id int, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
 
-	return err
+	standId, _ := input["stand_id"].(int)
+	quantity, _ := input["quantity"].(int)
+	jetons, _ := input["jetons"].(int)
+	commission, _ := input["commission"].(int)
+
+	err = tx.QueryRow(queryCreateInteraction, input["user_id"], input["kermesse_id"], standId, input["type"], jetons, input["promotion_id"], input["discount"], input["client_uuid"], input["created_at"], quantity, commission).Scan(&id)
+	if goErrors.Is(err, sql.ErrNoRows) {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// La demande de paiement scannée n'est consommée que si l'achat aboutit
+	if input["payment_request_id"] != nil {
+		result, err := tx.Exec(queryUseRequest, input["user_id"], input["payment_request_id"])
+		if err != nil {
+			return 0, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rows == 0 {
+			return 0, ErrRequestUsed
+		}
+	}
+
+	var standType string
+	var stock int
+	var capacity *int
+	if err = tx.QueryRow(queryLockStand, standId).Scan(&standType, &stock, &capacity); err != nil {
+		return 0, err
+	}
+	if standType == types.StandTypeVente {
+		if stock < quantity {
+			return 0, ErrOutOfStock
+		}
+		err = stand.MoveStock(tx, standId, -quantity, map[string]interface{}{
+			"user_id": input["user_id"],
+			"type":    types.StockMovementSale,
+		})
+		if err != nil {
+			return 0, err
+		}
+	} else if capacity != nil {
+		var occupancy int
+		if err = tx.Get(&occupancy, queryCountActivities, standId, types.InteractionTypeActivite, types.InteractionStatutStarted, id); err != nil {
+			return 0, err
+		}
+		if occupancy >= *capacity {
+			return 0, ErrStandFull
+		}
+	}
+
+	result, err := tx.Exec(queryDebitJetons, jetons, input["user_id"])
+	if err != nil {
+		return 0, err
+	}
+	debited, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if debited == 0 {
+		return 0, ErrNotEnoughJetons
+	}
+
+	if _, err = tx.Exec(queryCreditJetons, jetons-commission, input["owner_id"]); err != nil {
+		return 0, err
+	}
+	if commission > 0 {
+		err = kermesse.RecordTreasury(tx, map[string]interface{}{
+			"kermesse_id":    input["kermesse_id"],
+			"type":           types.TreasuryTransactionCommission,
+			"amount":         commission,
+			"interaction_id": id,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+// Rembourse l'interaction en une seule transaction : l'acheteur récupère ses jetons, repris
+// au teneur du stand (owner_id) et à la trésorerie, et le stock vendu revient au stand.
+// Renvoie false si l'interaction était déjà remboursée.
+func (s *Store) Refund(id int, input map[string]interface{}) (refunded bool, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var buyerId, kermesseId, standId, jetons, commission, quantity int
+	var interactionType string
+	err = tx.QueryRow(queryRefundInteraction, types.InteractionStatutEnded, id).Scan(&buyerId, &kermesseId, &standId, &interactionType, &jetons, &commission, &quantity)
+	if goErrors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if interactionType == types.InteractionTypeTransaction {
+		err = stand.MoveStock(tx, standId, quantity, map[string]interface{}{
+			"user_id": input["user_id"],
+			"type":    types.StockMovementRefund,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if _, err = tx.Exec(queryCreditJetons, jetons, buyerId); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(queryDebitJetons, jetons-commission, input["owner_id"])
+	if err != nil {
+		return false, err
+	}
+	debited, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if debited == 0 {
+		return false, ErrStandShort
+	}
+
+	if commission > 0 {
+		var treasury int
+		if err = tx.Get(&treasury, queryLockTreasury, kermesseId); err != nil {
+			return false, err
+		}
+		if treasury < commission {
+			return false, ErrTreasuryShort
+		}
+		err = kermesse.RecordTreasury(tx, map[string]interface{}{
+			"kermesse_id":    kermesseId,
+			"type":           types.TreasuryTransactionRefund,
+			"amount":         -commission,
+			"interaction_id": id,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
 }
 
 func (s *Store) Update(id int, input map[string]interface{}) error {
@@ -133,3 +352,22 @@ func (s *Store) Update(id int, input map[string]interface{}) error {
 
 	return err
 }
+
+// Clôture les activités restées STARTED au-delà du délai de leur stand
+// (ou du délai par défaut, en minutes) en leur attribuant les points par défaut du stand.
+func (s *Store) EndExpired(defaultTimeout int) ([]types.InteractionClosed, error) {
+	closed := []types.InteractionClosed{}
+	query := `
+		UPDATE interactions i
+		SET statut = $1, points = s.timeout_points
+		FROM stands s
+		WHERE i.stand_id = s.id
+		AND i.type = $2
+		AND i.statut = $3
+		AND i.created_at < NOW() - make_interval(mins => COALESCE(s.timeout_minutes, $4))
+		RETURNING i.id, i.user_id, i.stand_id, i.kermesse_id, i.points
+	`
+	err := s.db.Select(&closed, query, types.InteractionStatutEnded, types.InteractionTypeActivite, types.InteractionStatutStarted, defaultTimeout)
+
+	return closed, err
+}
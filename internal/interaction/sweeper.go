@@ -0,0 +1,35 @@
+package interaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Clôture périodiquement les activités abandonnées par les teneurs de stand.
+type Sweeper struct {
+	store          InteractionStore
+	defaultTimeout int
+}
+
+func NewSweeper(store InteractionStore, defaultTimeout int) *Sweeper {
+	return &Sweeper{
+		store:          store,
+		defaultTimeout: defaultTimeout,
+	}
+}
+
+// Tâche planifiée : clôture les activités dont le délai est dépassé.
+func (s *Sweeper) Sweep(ctx context.Context) (string, error) {
+	closed, err := s.store.EndExpired(s.defaultTimeout)
+	if err != nil || len(closed) == 0 {
+		return "", err
+	}
+
+	ids := []string{}
+	for _, interaction := range closed {
+		ids = append(ids, fmt.Sprintf("%d (stand %d, %d points)", interaction.Id, interaction.StandId, interaction.Points))
+	}
+
+	return "Interactions clôturées : " + strings.Join(ids, ", "), nil
+}
@@ -0,0 +1,141 @@
+package interaction
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	goErrors "errors"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/generator"
+	"github.com/chall-goflutter-api/pkg/signature"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+const defaultOfflineTokenTTL = 240
+
+// Délivre à l'enfant une autorisation de paiement hors ligne, à usage unique.
+// Le stand la joint à l'interaction synchronisée, qui ne peut débiter que cet enfant.
+func (s *Service) IssueOfflineToken(ctx context.Context, input map[string]interface{}) (types.OfflineToken, error) {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	kermesseId, err := utils.GetIntFromMap(input, "kermesse_id")
+	if err != nil {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	maxJetons, err := utils.GetIntFromMap(input, "max_jetons")
+	if err != nil || maxJetons <= 0 {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("max_jetons doit être un entier positif"),
+		}
+	}
+
+	kermesse, err := s.kermesseStore.FindById(kermesseId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return types.OfflineToken{}, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if kermesse.IsFinished() {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+	isParticipant, err := s.kermesseStore.IsParticipant(kermesseId, userId)
+	if err != nil {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !isParticipant {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("L'utilisateur ne participe pas à la kermesse"),
+		}
+	}
+
+	clientUuid, err := generator.RandomPassword(32)
+	if err != nil {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	ttl := utils.GetEnvInt("OFFLINE_TOKEN_TTL_MINUTES", defaultOfflineTokenTTL)
+	if ttl <= 0 {
+		ttl = defaultOfflineTokenTTL
+	}
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Minute)
+
+	token, err := encodeOfflineToken(s.tokenSecret, types.OfflineTokenClaims{
+		UserId:     userId,
+		KermesseId: kermesseId,
+		ClientUuid: clientUuid,
+		MaxJetons:  maxJetons,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	if err != nil {
+		return types.OfflineToken{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return types.OfflineToken{
+		Token:      token,
+		ClientUuid: clientUuid,
+		KermesseId: kermesseId,
+		MaxJetons:  maxJetons,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// Encode l'autorisation en "<claims en base64>.<signature>", comme les demandes de paiement.
+func encodeOfflineToken(secret string, claims types.OfflineTokenClaims) (string, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(raw)
+
+	return encoded + "." + signature.Sign(secret, []byte(encoded)), nil
+}
+
+func decodeOfflineToken(secret string, token string) (types.OfflineTokenClaims, error) {
+	claims := types.OfflineTokenClaims{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !signature.Verify(secret, []byte(parts[0]), parts[1]) {
+		return claims, goErrors.New("Signature de l'autorisation de paiement invalide")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, err
+	}
+	err = json.Unmarshal(raw, &claims)
+
+	return claims, err
+}
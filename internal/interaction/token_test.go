@@ -0,0 +1,26 @@
+package interaction
+
+import (
+	"testing"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+func TestDecodeOfflineToken(t *testing.T) {
+	claims := types.OfflineTokenClaims{UserId: 4, KermesseId: 7, ClientUuid: "abc", MaxJetons: 10, ExpiresAt: 1700000000}
+	token, err := encodeOfflineToken("secret", claims)
+	if err != nil {
+		t.Fatalf("encodeOfflineToken: %v", err)
+	}
+
+	decoded, err := decodeOfflineToken("secret", token)
+	if err != nil || decoded != claims {
+		t.Errorf("decodeOfflineToken = %v, %v, attendu %v", decoded, err, claims)
+	}
+	// Un jeton signé avec une autre clé, ou sans clé, est refusé
+	for _, secret := range []string{"autre", ""} {
+		if _, err := decodeOfflineToken(secret, token); err == nil {
+			t.Errorf("jeton accepté avec la clé %q", secret)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package kermesse
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+// Récupère une kermesse en vérifiant que l'utilisateur connecté fait partie de son équipe
+// avec un rôle qui accorde la permission demandée. C'est le seul point de contrôle
+// des droits de gestion d'une kermesse.
+func Authorize(ctx context.Context, store KermesseStore, id int, permission string) (types.Kermesse, error) {
+	kermesse, err := store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return kermesse, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return kermesse, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return kermesse, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	can, err := Can(store, id, userId, permission)
+	if err != nil {
+		return kermesse, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !can {
+		return kermesse, errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	return kermesse, nil
+}
+
+// Indique si l'utilisateur a la permission sur la kermesse, sans erreur s'il n'est pas membre.
+func Can(store KermesseStore, id int, userId int, permission string) (bool, error) {
+	role, err := store.FindMemberRole(id, userId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return types.KermesseRoleCan(role, permission), nil
+}
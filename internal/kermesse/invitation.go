@@ -0,0 +1,240 @@
+package kermesse
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/generator"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+const invitationCodeLength = 8
+
+func (s *Service) GetInvitations(ctx context.Context, id int) ([]types.KermesseInvitation, error) {
+	if _, err := Authorize(ctx, s.store, id, types.KermessePermissionManage); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.store.FindInvitations(id)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	for i := range invitations {
+		invitations[i].Link = invitationLink(invitations[i].Code)
+	}
+
+	return invitations, nil
+}
+
+func (s *Service) CreateInvitation(ctx context.Context, id int, input map[string]interface{}) (types.KermesseInvitation, error) {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return types.KermesseInvitation{}, err
+	}
+	if kermesse.IsFinished() {
+		return types.KermesseInvitation{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+	if kermesse.IsTemplate {
+		return types.KermesseInvitation{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Un modèle ne peut pas avoir de participants"),
+		}
+	}
+
+	var maxUses *int
+	if input["max_uses"] != nil {
+		value, err := utils.GetIntFromMap(input, "max_uses")
+		if err != nil || value <= 0 {
+			return types.KermesseInvitation{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Le nombre d'utilisations doit être positif"),
+			}
+		}
+		maxUses = &value
+	}
+	expiresAt, err := utils.GetTimeFromMap(input, "expires_at")
+	if err != nil {
+		return types.KermesseInvitation{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return types.KermesseInvitation{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La date d'expiration doit être dans le futur"),
+		}
+	}
+
+	code, err := generator.RandomCode(invitationCodeLength)
+	if err != nil {
+		return types.KermesseInvitation{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	invitation, err := s.store.CreateInvitation(map[string]interface{}{
+		"kermesse_id": id,
+		"code":        code,
+		"max_uses":    maxUses,
+		"expires_at":  expiresAt,
+	})
+	if err != nil {
+		return types.KermesseInvitation{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	invitation.Link = invitationLink(invitation.Code)
+
+	return invitation, nil
+}
+
+func (s *Service) DeleteInvitation(ctx context.Context, id int, invitationId int) error {
+	if _, err := Authorize(ctx, s.store, id, types.KermessePermissionManage); err != nil {
+		return err
+	}
+
+	found, err := s.store.DeleteInvitation(id, invitationId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Invitation non trouvée"),
+		}
+	}
+
+	return nil
+}
+
+// Un parent saisit le code reçu et inscrit les enfants choisis, il est inscrit avec eux.
+func (s *Service) Join(ctx context.Context, input map[string]interface{}) error {
+	code, ok := input["code"].(string)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("code est manquant ou n'est pas une chaîne de caractères"),
+		}
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	parentId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	childIds, ok := input["children"].([]interface{})
+	if !ok || len(childIds) == 0 {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Au moins un enfant doit être sélectionné"),
+		}
+	}
+	userIds := []int{parentId}
+	for _, value := range childIds {
+		childId, ok := value.(float64)
+		if !ok {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("children doit contenir des identifiants d'utilisateurs"),
+			}
+		}
+		child, err := s.userStore.FindById(int(childId))
+		if err != nil {
+			if goErrors.Is(err, sql.ErrNoRows) {
+				return errors.CustomError{
+					Key: errors.NotFound,
+					Err: err,
+				}
+			}
+			return errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if child.Role != types.UserRoleEnfant || child.ParentId == nil || *child.ParentId != parentId {
+			return errors.CustomError{
+				Key: errors.Forbidden,
+				Err: fmt.Errorf("L'utilisateur %d n'est pas votre enfant", child.Id),
+			}
+		}
+		userIds = append(userIds, child.Id)
+	}
+
+	invitation, err := s.store.FindInvitationByCode(code)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: goErrors.New("Code d'invitation inconnu"),
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	kermesse, err := s.store.FindById(invitation.KermesseId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if err := organisation.Check(ctx, kermesse.OrganisationId); err != nil {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Code d'invitation inconnu"),
+		}
+	}
+	if kermesse.IsFinished() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+
+	err = s.store.RedeemInvitation(code, userIds)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Le code d'invitation a expiré ou a atteint son nombre d'utilisations"),
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// Lien à partager, ouvert par l'application pour pré-remplir le code.
+func invitationLink(code string) string {
+	return fmt.Sprintf("%s?code=%s", os.Getenv("INVITATION_URL"), code)
+}
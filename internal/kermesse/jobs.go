@@ -0,0 +1,102 @@
+package kermesse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+// Tâche planifiée : ouvre les kermesses publiées dont la date de début est passée.
+func (s *Service) OpenScheduled(ctx context.Context) (string, error) {
+	kermesses, err := s.store.FindToOpen(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	opened := []string{}
+	for _, kermesse := range kermesses {
+		if err := s.transition(kermesse, types.KermesseStatutOpen); err != nil {
+			return summary("ouverte(s)", opened), err
+		}
+		opened = append(opened, fmt.Sprint(kermesse.Id))
+	}
+
+	return summary("ouverte(s)", opened), nil
+}
+
+// Tâche planifiée : clôture les kermesses ouvertes dont la date de fin est passée.
+// Une kermesse avec une tombola non tirée reste ouverte et son organisateur est prévenu une fois.
+func (s *Service) CloseScheduled(ctx context.Context) (string, error) {
+	kermesses, err := s.store.FindToClose(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	closed, blocked := []string{}, []string{}
+	for _, kermesse := range kermesses {
+		_, err := s.close(kermesse)
+		if err == nil {
+			closed = append(closed, fmt.Sprint(kermesse.Id))
+			continue
+		}
+		if e, ok := err.(errors.CustomError); !ok || e.Key != errors.BadRequest {
+			return summary("clôturée(s)", closed), err
+		}
+
+		warned, err := s.notificationStore.CreateOnce(map[string]interface{}{
+			"user_id": kermesse.UserId,
+			"type":    types.NotificationTypeKermesseClose,
+			"message": fmt.Sprintf("La kermesse %s n'a pas pu être clôturée automatiquement : une tombola est en cours", kermesse.Name),
+		})
+		if err != nil {
+			return summary("clôturée(s)", closed), err
+		}
+		if warned {
+			blocked = append(blocked, fmt.Sprint(kermesse.Id))
+		}
+	}
+
+	message := summary("clôturée(s)", closed)
+	if len(blocked) > 0 {
+		message = strings.TrimSpace(message + " " + summary("en attente de tirage", blocked))
+	}
+
+	return message, nil
+}
+
+// Tâche planifiée : anonymise les kermesses archivées depuis plus de retentionDays jours.
+func (s *Service) AnonymiseArchived(ctx context.Context, retentionDays int) (string, error) {
+	kermesses, err := s.store.FindToAnonymise(time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		return "", err
+	}
+
+	anonymised, users := []string{}, 0
+	for _, kermesse := range kermesses {
+		count, err := s.store.Anonymise(kermesse.Id)
+		if err != nil {
+			return summary("anonymisée(s)", anonymised), err
+		}
+		anonymised = append(anonymised, fmt.Sprint(kermesse.Id))
+		users += count
+	}
+
+	message := summary("anonymisée(s)", anonymised)
+	if users > 0 {
+		message += fmt.Sprintf(" (%d utilisateur(s))", users)
+	}
+
+	return message, nil
+}
+
+func summary(action string, ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("Kermesses %s : %s", action, strings.Join(ids, ", "))
+}
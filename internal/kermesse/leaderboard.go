@@ -0,0 +1,134 @@
+package kermesse
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// Classement de la kermesse, visible par son équipe et ses participants.
+// Les noms complets sont réservés à l'équipe, les autres voient le prénom et l'initiale.
+func (s *Service) GetLeaderboard(ctx context.Context, id int, params map[string]interface{}) (types.Leaderboard, error) {
+	_, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return types.Leaderboard{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return types.Leaderboard{}, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	isMember, err := Can(s.store, id, userId, types.KermessePermissionView)
+	if err != nil {
+		return types.Leaderboard{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !isMember {
+		isParticipant, err := s.store.IsParticipant(id, userId)
+		if err != nil {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !isParticipant {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interdit"),
+			}
+		}
+	}
+
+	leaderboard := types.Leaderboard{
+		KermesseId: id,
+		GroupBy:    types.LeaderboardGroupChild,
+		Privacy:    types.LeaderboardPrivacyInitials,
+	}
+	if groupBy, ok := params["group_by"].(string); ok {
+		if groupBy != types.LeaderboardGroupChild && groupBy != types.LeaderboardGroupClass && groupBy != types.LeaderboardGroupFamily {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: fmt.Errorf("Regroupement inconnu : %s", groupBy),
+			}
+		}
+		leaderboard.GroupBy = groupBy
+	}
+	if privacy, ok := params["privacy"].(string); ok {
+		if privacy != types.LeaderboardPrivacyInitials && privacy != types.LeaderboardPrivacyFull {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: fmt.Errorf("Option de confidentialité inconnue : %s", privacy),
+			}
+		}
+		if privacy == types.LeaderboardPrivacyFull && !isMember {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Les noms complets sont réservés à l'équipe de la kermesse"),
+			}
+		}
+		leaderboard.Privacy = privacy
+	}
+	limit := defaultLeaderboardLimit
+	if value, ok := params["limit"].(string); ok {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxLeaderboardLimit {
+			return types.Leaderboard{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: fmt.Errorf("limit doit être compris entre 1 et %d", maxLeaderboardLimit),
+			}
+		}
+	}
+
+	leaderboard.Entries, err = s.store.Leaderboard(id, leaderboard.GroupBy, limit)
+	if err != nil {
+		return types.Leaderboard{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if leaderboard.Privacy == types.LeaderboardPrivacyInitials && leaderboard.GroupBy != types.LeaderboardGroupClass {
+		for i := range leaderboard.Entries {
+			leaderboard.Entries[i].Name = shortName(leaderboard.Entries[i].Name)
+		}
+	}
+	leaderboard.UpdatedAt = time.Now()
+
+	return leaderboard, nil
+}
+
+// "Léa Martin" devient "Léa M.".
+func shortName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name
+	}
+	initial := []rune(parts[len(parts)-1])[0]
+
+	return fmt.Sprintf("%s %c.", parts[0], initial)
+}
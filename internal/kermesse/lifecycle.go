@@ -0,0 +1,103 @@
+package kermesse
+
+import (
+	goErrors "errors"
+	"fmt"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+// Passe la kermesse au statut demandé si la transition est autorisée.
+func (s *Service) transition(kermesse types.Kermesse, statut string) error {
+	if !kermesse.CanTransition(statut) {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: fmt.Errorf("Impossible de passer la kermesse de %s à %s", kermesse.Statut, statut),
+		}
+	}
+
+	err := s.store.UpdateStatut(kermesse.Id, statut)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// Clôture la kermesse une fois les tombolas tirées, en terminant les activités restées ouvertes.
+func (s *Service) close(kermesse types.Kermesse) (types.KermesseEnd, error) {
+	if !kermesse.CanTransition(types.KermesseStatutClosed) {
+		return types.KermesseEnd{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Seule une kermesse ouverte peut être terminée"),
+		}
+	}
+
+	canEnd, err := s.store.CanEnd(kermesse.Id)
+	if err != nil {
+		return types.KermesseEnd{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !canEnd {
+		return types.KermesseEnd{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse ne peut pas être terminée, car il y a une tombola en cours"),
+		}
+	}
+
+	closed, err := s.store.EndInteractions(kermesse.Id)
+	if err != nil {
+		return types.KermesseEnd{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	err = s.store.UpdateStatut(kermesse.Id, types.KermesseStatutClosed)
+	if err != nil {
+		return types.KermesseEnd{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return types.KermesseEnd{
+		Id:                 kermesse.Id,
+		ClosedInteractions: closed,
+	}, nil
+}
+
+// Valide les dates d'ouverture et de clôture, en conservant les valeurs actuelles si elles ne sont pas fournies.
+func prepareDates(input map[string]interface{}, current types.Kermesse) error {
+	startsAt, endsAt := current.StartsAt, current.EndsAt
+
+	if _, ok := input["starts_at"]; ok {
+		value, err := utils.GetTimeFromMap(input, "starts_at")
+		if err != nil {
+			return err
+		}
+		startsAt = value
+	}
+	if _, ok := input["ends_at"]; ok {
+		value, err := utils.GetTimeFromMap(input, "ends_at")
+		if err != nil {
+			return err
+		}
+		endsAt = value
+	}
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return goErrors.New("La date de fin doit être postérieure à la date de début")
+	}
+
+	input["starts_at"] = startsAt
+	input["ends_at"] = endsAt
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package kermesse
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+
+	"github.com/chall-goflutter-api/internal/media"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+const maxZoneLength = 100
+
+func (s *Service) GetMap(ctx context.Context, id int) (types.KermesseMap, error) {
+	kermesse, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return types.KermesseMap{}, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return types.KermesseMap{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	stands, err := s.store.FindMapStands(id)
+	if err != nil {
+		return types.KermesseMap{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	// Un stand est ouvert pendant la kermesse tant qu'il lui reste du stock à vendre
+	for i, stand := range stands {
+		stands[i].IsOpen = kermesse.Statut == types.KermesseStatutOpen && (stand.Type != types.StandTypeVente || stand.Stock > 0)
+	}
+
+	return types.KermesseMap{
+		KermesseId:      id,
+		MapUrl:          media.URL(s.files, kermesse.MapKey),
+		MapThumbnailUrl: media.URL(s.files, kermesse.MapThumbnailKey),
+		Stands:          stands,
+	}, nil
+}
+
+func (s *Service) UploadMap(ctx context.Context, id int, data []byte) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	key, thumbnailKey, err := media.SaveImage(s.files, fmt.Sprintf("kermesses/%d/map", id), data)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdateMap(id, key, thumbnailKey); err != nil {
+		media.Delete(s.files, &key, &thumbnailKey)
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	media.Delete(s.files, kermesse.MapKey, kermesse.MapThumbnailKey)
+
+	return nil
+}
+
+// Place un stand sur le plan, par coordonnées relatives à l'image (0 à 1), par zone, ou les deux.
+// Les champs absents sont effacés.
+func (s *Service) UpdateStandPosition(ctx context.Context, id int, standId int, input map[string]interface{}) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+	if kermesse.IsFinished() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+
+	position := map[string]interface{}{
+		"pos_x": nil,
+		"pos_y": nil,
+		"zone":  nil,
+	}
+	if (input["pos_x"] == nil) != (input["pos_y"] == nil) {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("pos_x et pos_y vont ensemble"),
+		}
+	}
+	for _, key := range []string{"pos_x", "pos_y"} {
+		if input[key] == nil {
+			continue
+		}
+		value, ok := input[key].(float64)
+		if !ok || value < 0 || value > 1 {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: fmt.Errorf("%s doit être un nombre compris entre 0 et 1", key),
+			}
+		}
+		position[key] = value
+	}
+	if input["zone"] != nil {
+		zone, ok := input["zone"].(string)
+		if !ok || zone == "" || len([]rune(zone)) > maxZoneLength {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: fmt.Errorf("zone doit contenir entre 1 et %d caractères", maxZoneLength),
+			}
+		}
+		position["zone"] = zone
+	}
+
+	found, err := s.store.UpdateStandPosition(id, standId, position)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Le stand ne participe pas à cette kermesse"),
+		}
+	}
+
+	return nil
+}
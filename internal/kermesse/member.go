@@ -0,0 +1,117 @@
+package kermesse
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+func (s *Service) GetMembers(ctx context.Context, id int) ([]types.KermesseMember, error) {
+	if _, err := Authorize(ctx, s.store, id, types.KermessePermissionView); err != nil {
+		return nil, err
+	}
+
+	members, err := s.store.FindMembers(id)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return members, nil
+}
+
+// Ajoute un membre à l'équipe ou change son rôle. Le propriétaire reste unique et inchangé.
+func (s *Service) SaveMember(ctx context.Context, id int, input map[string]interface{}) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionOwn)
+	if err != nil {
+		return err
+	}
+
+	memberId, err := utils.GetIntFromMap(input, "user_id")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	role, ok := input["role"].(string)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("role est manquant ou n'est pas une chaîne de caractères"),
+		}
+	}
+	if !types.IsKermesseMemberRole(role) || role == types.KermesseMemberOwner {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Rôle invalide"),
+		}
+	}
+	if memberId == kermesse.UserId {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le rôle du propriétaire ne peut pas être modifié"),
+		}
+	}
+
+	member, err := s.userStore.FindById(memberId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if err := organisation.Check(ctx, member.OrganisationId); err != nil {
+		return err
+	}
+	if member.Role == types.UserRoleEnfant {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Un enfant ne peut pas faire partie de l'équipe"),
+		}
+	}
+
+	if err := s.store.SaveMember(id, memberId, role); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteMember(ctx context.Context, id int, memberId int) error {
+	if _, err := Authorize(ctx, s.store, id, types.KermessePermissionOwn); err != nil {
+		return err
+	}
+
+	found, err := s.store.DeleteMember(id, memberId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Membre non trouvé"),
+		}
+	}
+
+	return nil
+}
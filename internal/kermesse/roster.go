@@ -0,0 +1,409 @@
+package kermesse
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	goErrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"strings"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/internal/user"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/generator"
+	"github.com/chall-goflutter-api/pkg/hasher"
+)
+
+const (
+	MaxRosterSize  = 1 << 20 // 1 Mo
+	maxRosterRows  = 2000
+	passwordLength = 32
+	maxClassLength = 50
+)
+
+var rosterColumns = []string{"parent_name", "parent_email", "child_name"}
+
+type rosterLine struct {
+	line        int
+	parentName  string
+	parentEmail string
+	childName   string
+	childEmail  string
+	childClass  string
+}
+
+// Famille rencontrée pendant l'import, pour l'email d'invitation.
+// Un compte reste à créer tant que son id vaut 0, link est renseigné pour les comptes créés.
+type rosterFamily struct {
+	id             int
+	organisationId int
+	name           string
+	email          string
+	link           string
+	children       map[string]*rosterChild
+}
+
+type rosterChild struct {
+	id    int
+	name  string
+	email string
+	link  string
+}
+
+// Importe une liste de familles (parent_name, parent_email, child_name, puis child_email et child_class optionnels),
+// crée les comptes manquants et les inscrit à la kermesse. En simulation rien n'est écrit.
+// Chaque ligne est écrite dans sa propre transaction : une ligne en erreur ne laisse aucun compte orphelin.
+func (s *Service) ImportRoster(ctx context.Context, id int, data []byte, dryRun bool) (types.RosterImport, error) {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return types.RosterImport{}, err
+	}
+	if err := checkEnrolment(kermesse); err != nil {
+		return types.RosterImport{}, err
+	}
+
+	lines, err := parseRoster(data)
+	if err != nil {
+		return types.RosterImport{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	report := types.RosterImport{
+		DryRun: dryRun,
+		Total:  len(lines),
+		Rows:   []types.RosterRow{},
+	}
+	families := map[string]*rosterFamily{}
+	for _, line := range lines {
+		row := types.RosterRow{
+			Line:        line.line,
+			ParentEmail: line.parentEmail,
+			ChildName:   line.childName,
+		}
+		if err := s.importLine(ctx, kermesse, line, families, &row, dryRun); err != nil {
+			row.Error = err.Error()
+			report.Failed++
+		} else {
+			report.Imported++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if !dryRun {
+		for _, family := range families {
+			// Aucune ligne de la famille n'a été importée
+			if len(family.children) == 0 {
+				continue
+			}
+			if err := s.invite(kermesse, family); err != nil {
+				log.Printf("Error sending invitation to %s: %v", family.email, err)
+				continue
+			}
+			report.Invited++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *Service) importLine(ctx context.Context, kermesse types.Kermesse, line rosterLine, families map[string]*rosterFamily, row *types.RosterRow, dryRun bool) error {
+	if line.parentName == "" || line.childName == "" {
+		return goErrors.New("Le nom du parent et celui de l'enfant sont requis")
+	}
+	parentEmail, err := parseEmail(line.parentEmail)
+	if err != nil {
+		return err
+	}
+	row.ParentEmail = parentEmail
+
+	family, ok := families[parentEmail]
+	if !ok {
+		family, err = s.importParent(kermesse.OrganisationId, line.parentName, parentEmail)
+		if err != nil {
+			return err
+		}
+		families[parentEmail] = family
+	}
+	row.ParentStatut = types.RosterStatutExisting
+	if family.id == 0 && (!ok || !dryRun) {
+		row.ParentStatut = types.RosterStatutCreated
+	}
+
+	childEmail := childLogin(parentEmail, line.childName)
+	if line.childEmail != "" {
+		childEmail, err = parseEmail(line.childEmail)
+		if err != nil {
+			return err
+		}
+	}
+	if len([]rune(line.childClass)) > maxClassLength {
+		return fmt.Errorf("La classe ne peut pas dépasser %d caractères", maxClassLength)
+	}
+	child, err := s.importChild(family, line.childName, childEmail)
+	if err != nil {
+		return err
+	}
+	row.ChildStatut = types.RosterStatutExisting
+	if child.id == 0 {
+		row.ChildStatut = types.RosterStatutCreated
+	}
+
+	// Mêmes vérifications qu'une inscription par AddParticipant
+	if child.id != 0 {
+		existing, err := s.userStore.FindById(child.id)
+		if err != nil {
+			return err
+		}
+		if err := checkParticipant(ctx, existing); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		family.children[strings.ToLower(child.name)] = child
+		return nil
+	}
+
+	input := map[string]interface{}{
+		"kermesse_id":     kermesse.Id,
+		"organisation_id": family.organisationId,
+		"parent_id":       family.id,
+		"parent_name":     family.name,
+		"parent_email":    family.email,
+		"child_id":        child.id,
+		"child_name":      child.name,
+		"child_email":     child.email,
+		"child_class":     nil,
+	}
+	if line.childClass != "" {
+		input["child_class"] = line.childClass
+	}
+	var parentHash, childHash string
+	if family.id == 0 {
+		if parentHash, err = randomPasswordHash(); err != nil {
+			return err
+		}
+		input["parent_password"] = parentHash
+	}
+	if child.id == 0 {
+		if childHash, err = randomPasswordHash(); err != nil {
+			return err
+		}
+		input["child_password"] = childHash
+	}
+
+	parentId, childId, err := s.store.ImportFamily(input)
+	if err != nil {
+		return err
+	}
+
+	// Les comptes créés reçoivent un lien pour choisir leur mot de passe
+	if family.id == 0 {
+		family.id = parentId
+		if family.link, err = user.PasswordLink(types.User{Id: parentId, PasswordHash: parentHash}); err != nil {
+			return err
+		}
+	}
+	if child.id == 0 {
+		child.id = childId
+		if child.link, err = user.PasswordLink(types.User{Id: childId, PasswordHash: childHash}); err != nil {
+			return err
+		}
+	}
+	family.children[strings.ToLower(child.name)] = child
+
+	return nil
+}
+
+func (s *Service) importParent(organisationId int, name string, email string) (*rosterFamily, error) {
+	family := &rosterFamily{
+		organisationId: organisationId,
+		name:           name,
+		email:          email,
+		children:       map[string]*rosterChild{},
+	}
+
+	existing, err := s.userStore.FindByEmail(email)
+	if err == nil {
+		if existing.OrganisationId != organisationId {
+			return nil, fmt.Errorf("L'email %s est déjà utilisé", email)
+		}
+		if existing.Role != types.UserRoleParent {
+			return nil, fmt.Errorf("L'email %s appartient à un compte qui n'est pas parent", email)
+		}
+		family.id = existing.Id
+		family.name = existing.Name
+		return family, nil
+	}
+	if !goErrors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return family, nil
+}
+
+// Retrouve l'enfant du parent par son nom ou son email, sinon il reste à créer (id à 0).
+func (s *Service) importChild(family *rosterFamily, name string, email string) (*rosterChild, error) {
+	if child, ok := family.children[strings.ToLower(name)]; ok {
+		return child, nil
+	}
+
+	if family.id != 0 {
+		children, err := s.userStore.FindChildren(family.id, map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if strings.EqualFold(child.Name, name) {
+				return &rosterChild{id: child.Id, name: child.Name, email: child.Email}, nil
+			}
+		}
+	}
+
+	existing, err := s.userStore.FindByEmail(email)
+	if err == nil {
+		if existing.Role != types.UserRoleEnfant || existing.ParentId == nil || *existing.ParentId != family.id {
+			return nil, fmt.Errorf("L'email %s est déjà utilisé", email)
+		}
+		return &rosterChild{id: existing.Id, name: existing.Name, email: existing.Email}, nil
+	}
+	if !goErrors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return &rosterChild{
+		name:  name,
+		email: email,
+	}, nil
+}
+
+// Mot de passe aléatoire jamais communiqué : le compte est activé par le lien de l'invitation.
+func randomPasswordHash() (string, error) {
+	password, err := generator.RandomPassword(passwordLength)
+	if err != nil {
+		return "", err
+	}
+
+	return hasher.Hash(password)
+}
+
+func (s *Service) invite(kermesse types.Kermesse, family *rosterFamily) error {
+	body := strings.Builder{}
+	fmt.Fprintf(&body, "Bonjour %s,\n\n", family.name)
+	fmt.Fprintf(&body, "Votre famille est inscrite à la kermesse %s.\n", kermesse.Name)
+	hasLink := family.link != ""
+	if family.link != "" {
+		fmt.Fprintf(&body, "\nVotre compte : %s\nChoisissez votre mot de passe : %s\n", family.email, family.link)
+	}
+	for _, child := range family.children {
+		fmt.Fprintf(&body, "\n%s : %s", child.name, child.email)
+		if child.link != "" {
+			fmt.Fprintf(&body, "\nChoisissez son mot de passe : %s", child.link)
+			hasLink = true
+		}
+	}
+	if hasLink {
+		body.WriteString("\n\nLes liens pour choisir un mot de passe sont valables 7 jours.")
+	}
+	body.WriteString("\n")
+
+	return s.mailer.Send(family.email, fmt.Sprintf("Invitation à la kermesse %s", kermesse.Name), body.String())
+}
+
+// Lit le CSV (séparateur "," ou ";", tel qu'exporté par les tableurs) et vérifie l'en-tête.
+func parseRoster(data []byte) ([]rosterLine, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, goErrors.New("Fichier CSV vide ou invalide")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range rosterColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("Colonne %s manquante", name)
+		}
+	}
+	value := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	lines := []rosterLine{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV invalide : %v", err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(lines) == maxRosterRows {
+			return nil, fmt.Errorf("Le fichier ne peut pas contenir plus de %d lignes", maxRosterRows)
+		}
+		line, _ := reader.FieldPos(0)
+		lines = append(lines, rosterLine{
+			line:        line,
+			parentName:  value(record, "parent_name"),
+			parentEmail: value(record, "parent_email"),
+			childName:   value(record, "child_name"),
+			childEmail:  value(record, "child_email"),
+			childClass:  value(record, "child_class"),
+		})
+	}
+
+	return lines, nil
+}
+
+func parseEmail(value string) (string, error) {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		return "", fmt.Errorf("Email invalide : %s", value)
+	}
+
+	return strings.ToLower(address.Address), nil
+}
+
+var accents = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "ô", "o", "ö", "o", "ù", "u", "û", "u", "ü", "u", "ÿ", "y",
+)
+
+// Identifiant de l'enfant sans email : adresse du parent avec un suffixe, par exemple parent+lea@ecole.fr.
+func childLogin(parentEmail string, childName string) string {
+	slug := strings.Builder{}
+	for _, r := range accents.Replace(strings.ToLower(childName)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+		}
+	}
+	if slug.Len() == 0 {
+		slug.WriteString("enfant")
+	}
+
+	local, domain, _ := strings.Cut(parentEmail, "@")
+	return fmt.Sprintf("%s+%s@%s", local, slug.String(), domain)
+}
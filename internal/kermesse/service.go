@@ -4,37 +4,71 @@ import (
 	"context"
 	"database/sql"
 	goErrors "errors"
+	"fmt"
 
+	"github.com/chall-goflutter-api/internal/media"
+	"github.com/chall-goflutter-api/internal/notification"
+	"github.com/chall-goflutter-api/internal/organisation"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/chall-goflutter-api/internal/user"
 	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/mailer"
+	"github.com/chall-goflutter-api/pkg/storage"
 	"github.com/chall-goflutter-api/pkg/utils"
 )
 
 type KermesseService interface {
-	GetAll(ctx context.Context) ([]types.Kermesse, error)
+	GetAll(ctx context.Context, params map[string]interface{}) ([]types.Kermesse, error)
 	GetUsersInvite(ctx context.Context, id int) ([]types.UserBasic, error)
 	Get(ctx context.Context, id int) (types.Kermesse, error)
 	Create(ctx context.Context, input map[string]interface{}) error
 	Update(ctx context.Context, id int, input map[string]interface{}) error
 	AddParticipant(ctx context.Context, input map[string]interface{}) error
 	AddStand(ctx context.Context, input map[string]interface{}) error
-	End(ctx context.Context, id int) error
+	End(ctx context.Context, id int) (types.KermesseEnd, error)
+	UpdateStatut(ctx context.Context, id int, input map[string]interface{}) error
+	GetInvitations(ctx context.Context, id int) ([]types.KermesseInvitation, error)
+	CreateInvitation(ctx context.Context, id int, input map[string]interface{}) (types.KermesseInvitation, error)
+	DeleteInvitation(ctx context.Context, id int, invitationId int) error
+	Join(ctx context.Context, input map[string]interface{}) error
+	ImportRoster(ctx context.Context, id int, data []byte, dryRun bool) (types.RosterImport, error)
+	UploadBanner(ctx context.Context, id int, data []byte) error
+	GetMap(ctx context.Context, id int) (types.KermesseMap, error)
+	UploadMap(ctx context.Context, id int, data []byte) error
+	UpdateStandPosition(ctx context.Context, id int, standId int, input map[string]interface{}) error
+	UpdateCommission(ctx context.Context, id int, input map[string]interface{}) error
+	UpdateStandCommission(ctx context.Context, id int, standId int, input map[string]interface{}) error
+	GetTreasury(ctx context.Context, id int) (types.Treasury, error)
+	GetStats(ctx context.Context, id int, params map[string]interface{}) (types.KermesseStats, error)
+	GetMembers(ctx context.Context, id int) ([]types.KermesseMember, error)
+	SaveMember(ctx context.Context, id int, input map[string]interface{}) error
+	DeleteMember(ctx context.Context, id int, memberId int) error
+	GetTemplates(ctx context.Context) ([]types.Kermesse, error)
+	Clone(ctx context.Context, id int, input map[string]interface{}) (types.KermesseClone, error)
+	SaveTemplate(ctx context.Context, id int, input map[string]interface{}) (types.KermesseClone, error)
+	Delete(ctx context.Context, id int) error
+	GetLeaderboard(ctx context.Context, id int, params map[string]interface{}) (types.Leaderboard, error)
 }
 
 type Service struct {
-	store     KermesseStore
-	userStore user.UserStore
+	store             KermesseStore
+	userStore         user.UserStore
+	notificationStore notification.NotificationStore
+	files             storage.Storage
+	mailer            mailer.Mailer
 }
 
-func NewService(store KermesseStore, userStore user.UserStore) *Service {
+func NewService(store KermesseStore, userStore user.UserStore, notificationStore notification.NotificationStore, files storage.Storage, mailer mailer.Mailer) *Service {
 	return &Service{
-		store:     store,
-		userStore: userStore,
+		store:             store,
+		userStore:         userStore,
+		notificationStore: notificationStore,
+		files:             files,
+		mailer:            mailer,
 	}
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]types.Kermesse, error) {
+func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]types.Kermesse, error) {
 	userId, ok := ctx.Value(types.UserIDKey).(int)
 	if !ok {
 		return nil, errors.CustomError{
@@ -50,15 +84,23 @@ func (s *Service) GetAll(ctx context.Context) ([]types.Kermesse, error) {
 		}
 	}
 
-	filtres := map[string]interface{}{}
+	filtres := map[string]interface{}{"is_template": false}
+	if params["archived"] == "true" {
+		filtres["archived"] = true
+	}
 	if userRole == types.UserRoleOrganisateur {
 		filtres["organisateur_id"] = userId
 	} else if userRole == types.UserRoleParent {
 		filtres["parent_id"] = userId
+		filtres["member_id"] = userId
 	} else if userRole == types.UserRoleEnfant {
 		filtres["child_id"] = userId
 	} else if userRole == types.UserRoleTeneurStand {
 		filtres["teneur_stand_id"] = userId
+		filtres["member_id"] = userId
+	}
+	if err := organisation.Scope(ctx, filtres); err != nil {
+		return nil, err
 	}
 
 	kermesses, err := s.store.FindAll(filtres)
@@ -68,6 +110,9 @@ func (s *Service) GetAll(ctx context.Context) ([]types.Kermesse, error) {
 			Err: err,
 		}
 	}
+	for i := range kermesses {
+		s.signUrls(&kermesses[i])
+	}
 
 	return kermesses, nil
 }
@@ -98,6 +143,7 @@ func (s *Service) Get(ctx context.Context, id int) (types.Kermesse, error) {
 			Err: err,
 		}
 	}
+	s.signUrls(&kermesse)
 
 	return kermesse, nil
 }
@@ -111,8 +157,20 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 	input["user_id"] = userId
+	organisationId, err := organisation.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	input["organisation_id"] = organisationId
 
-	err := s.store.Create(input)
+	if err := prepareDates(input, types.Kermesse{}); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	err = s.store.Create(input)
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
@@ -124,38 +182,22 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 }
 
 func (s *Service) Update(ctx context.Context, id int, input map[string]interface{}) error {
-	kermesse, err := s.store.FindById(id)
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
 	if err != nil {
-		if goErrors.Is(err, sql.ErrNoRows) {
-			return errors.CustomError{
-				Key: errors.NotFound,
-				Err: err,
-			}
-		}
-		return errors.CustomError{
-			Key: errors.InternalServerError,
-			Err: err,
-		}
+		return err
 	}
 
-	if kermesse.Statut == types.KermesseStatutEnded {
+	if !kermesse.IsEditable() {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("La kermesse est déjà terminée"),
+			Err: goErrors.New("La kermesse ne peut plus être modifiée"),
 		}
 	}
 
-	userId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
+	if err := prepareDates(input, kermesse); err != nil {
 		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
-		}
-	}
-	if kermesse.UserId != userId {
-		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit de modifier la kermesse d'un autre utilisateur"),
+			Key: errors.BadRequest,
+			Err: err,
 		}
 	}
 
@@ -171,39 +213,12 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 }
 
 func (s *Service) AddParticipant(ctx context.Context, input map[string]interface{}) error {
-	kermesse, err := s.store.FindById(input["kermesse_id"].(int))
+	kermesse, err := Authorize(ctx, s.store, input["kermesse_id"].(int), types.KermessePermissionManage)
 	if err != nil {
-		if goErrors.Is(err, sql.ErrNoRows) {
-			return errors.CustomError{
-				Key: errors.NotFound,
-				Err: err,
-			}
-		}
-		return errors.CustomError{
-			Key: errors.InternalServerError,
-			Err: err,
-		}
-	}
-
-	if kermesse.Statut == types.KermesseStatutEnded {
-		return errors.CustomError{
-			Key: errors.BadRequest,
-			Err: goErrors.New("La kermesse est déjà terminée"),
-		}
-	}
-
-	managerId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
-		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("Id utilisateur non trouvé dans le contexte"),
-		}
+		return err
 	}
-	if kermesse.UserId != managerId {
-		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit"),
-		}
+	if err := checkEnrolment(kermesse); err != nil {
+		return err
 	}
 
 	childId, error := utils.GetIntFromMap(input, "user_id")
@@ -226,11 +241,8 @@ func (s *Service) AddParticipant(ctx context.Context, input map[string]interface
 			Err: err,
 		}
 	}
-	if child.Role != types.UserRoleEnfant {
-		return errors.CustomError{
-			Key: errors.BadRequest,
-			Err: goErrors.New("L'utilisateur n'est pas un enfant"),
-		}
+	if err := checkParticipant(ctx, child); err != nil {
+		return err
 	}
 
 	// Inviter l'enfant
@@ -257,22 +269,46 @@ func (s *Service) AddParticipant(ctx context.Context, input map[string]interface
 	return nil
 }
 
-func (s *Service) AddStand(ctx context.Context, input map[string]interface{}) error {
-	kermesse, err := s.store.FindById(input["kermesse_id"].(int))
-	if err != nil {
-		if goErrors.Is(err, sql.ErrNoRows) {
-			return errors.CustomError{
-				Key: errors.NotFound,
-				Err: err,
-			}
+// La kermesse accepte-t-elle encore des participants ?
+func checkEnrolment(kermesse types.Kermesse) error {
+	if kermesse.IsFinished() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
 		}
+	}
+	if kermesse.IsTemplate {
 		return errors.CustomError{
-			Key: errors.InternalServerError,
-			Err: err,
+			Key: errors.BadRequest,
+			Err: goErrors.New("Un modèle ne peut pas avoir de participants"),
 		}
 	}
 
-	if kermesse.Statut == types.KermesseStatutEnded {
+	return nil
+}
+
+// Seul un enfant de l'école de l'utilisateur peut être inscrit.
+func checkParticipant(ctx context.Context, child types.User) error {
+	if err := organisation.Check(ctx, child.OrganisationId); err != nil {
+		return err
+	}
+	if child.Role != types.UserRoleEnfant {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'utilisateur n'est pas un enfant"),
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) AddStand(ctx context.Context, input map[string]interface{}) error {
+	kermesse, err := Authorize(ctx, s.store, input["kermesse_id"].(int), types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if kermesse.IsFinished() {
 		return errors.CustomError{
 			Key: errors.BadRequest,
 			Err: goErrors.New("La kermesse est déjà terminée"),
@@ -300,88 +336,215 @@ func (s *Service) AddStand(ctx context.Context, input map[string]interface{}) er
 		}
 	}
 
-	userId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
+	found, err := s.store.AddStand(input)
+	if err != nil {
 		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+			Key: errors.InternalServerError,
+			Err: err,
 		}
 	}
-	if kermesse.UserId != userId {
+	if !found {
 		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit"),
+			Key: errors.NotFound,
+			Err: goErrors.New("Stand non trouvé"),
 		}
 	}
 
-	err = s.store.AddStand(input)
+	return nil
+}
+
+func (s *Service) End(ctx context.Context, id int) (types.KermesseEnd, error) {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
 	if err != nil {
+		return types.KermesseEnd{}, err
+	}
+
+	return s.close(kermesse)
+}
+
+func (s *Service) UpdateStatut(ctx context.Context, id int, input map[string]interface{}) error {
+	statut, ok := input["statut"].(string)
+	if !ok {
 		return errors.CustomError{
-			Key: errors.InternalServerError,
-			Err: err,
+			Key: errors.BadRequest,
+			Err: goErrors.New("statut est manquant ou n'est pas une chaîne de caractères"),
 		}
 	}
 
-	return nil
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if statut == types.KermesseStatutClosed {
+		_, err = s.close(kermesse)
+		return err
+	}
+
+	return s.transition(kermesse, statut)
 }
 
-func (s *Service) End(ctx context.Context, id int) error {
-	kermesse, err := s.store.FindById(id)
+// Supprime la kermesse de façon logique, sauf pendant son ouverture.
+func (s *Service) Delete(ctx context.Context, id int) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionOwn)
 	if err != nil {
-		if goErrors.Is(err, sql.ErrNoRows) {
-			return errors.CustomError{
-				Key: errors.NotFound,
-				Err: err,
-			}
+		return err
+	}
+
+	if kermesse.Statut == types.KermesseStatutOpen {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Une kermesse ouverte ne peut pas être supprimée"),
 		}
+	}
+	// Les fonds de la kermesse ne doivent pas disparaître avec elle
+	if kermesse.TreasuryJetons != 0 {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La trésorerie de la kermesse n'est pas soldée, elle ne peut qu'être archivée"),
+		}
+	}
+	noTombola, err := s.store.CanEnd(id)
+	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
-
-	if kermesse.Statut == types.KermesseStatutEnded {
+	if !noTombola {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("La kermeese est déjà terminée"),
+			Err: goErrors.New("La kermesse a une tombola en cours, annulez-la pour rembourser les tickets"),
 		}
 	}
 
-	canEnd, err := s.store.CanEnd(id)
+	err = s.store.Delete(id)
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
-	if !canEnd {
+
+	return nil
+}
+
+func (s *Service) UploadBanner(ctx context.Context, id int, data []byte) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	key, thumbnailKey, err := media.SaveImage(s.files, fmt.Sprintf("kermesses/%d", id), data)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdateBanner(id, key, thumbnailKey); err != nil {
+		media.Delete(s.files, &key, &thumbnailKey)
 		return errors.CustomError{
-			Key: errors.BadRequest,
-			Err: goErrors.New("La kermesse ne peut pas être terminée, car il y a une tombola en cours"),
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	media.Delete(s.files, kermesse.BannerKey, kermesse.BannerThumbnailKey)
+
+	return nil
+}
+
+func (s *Service) GetStats(ctx context.Context, id int, params map[string]interface{}) (types.KermesseStats, error) {
+	_, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return types.KermesseStats{}, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return types.KermesseStats{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
 		}
 	}
 
 	userId, ok := ctx.Value(types.UserIDKey).(int)
 	if !ok {
-		return errors.CustomError{
+		return types.KermesseStats{}, errors.CustomError{
 			Key: errors.Unauthorized,
 			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
 		}
 	}
-	if kermesse.UserId != userId {
-		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit"),
+	userRole, ok := ctx.Value(types.UserRoleKey).(string)
+	if !ok {
+		return types.KermesseStats{}, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("Role utilisateur non trouvé dans le contexte"),
 		}
 	}
 
-	err = s.store.End(id)
+	isMember, err := Can(s.store, id, userId, types.KermessePermissionView)
 	if err != nil {
-		return errors.CustomError{
+		return types.KermesseStats{}, errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
 
-	return nil
+	// L'équipe voit toute la kermesse, le teneur son stand, le parent sa famille
+	filtres := map[string]interface{}{}
+	if !isMember {
+		if userRole == types.UserRoleTeneurStand {
+			filtres["teneur_stand_id"] = userId
+		} else if userRole == types.UserRoleParent {
+			filtres["parent_id"] = userId
+		} else {
+			return types.KermesseStats{}, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interdit"),
+			}
+		}
+	}
+
+	from, err := utils.GetTimeFromMap(params, "from")
+	if err != nil {
+		return types.KermesseStats{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	to, err := utils.GetTimeFromMap(params, "to")
+	if err != nil {
+		return types.KermesseStats{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return types.KermesseStats{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La date de début doit précéder la date de fin"),
+		}
+	}
+	if from != nil {
+		filtres["from"] = *from
+	}
+	if to != nil {
+		filtres["to"] = *to
+	}
+
+	stats, err := s.store.Stats(id, filtres)
+	if err != nil {
+		return types.KermesseStats{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *Service) signUrls(kermesse *types.Kermesse) {
+	kermesse.BannerUrl = media.URL(s.files, kermesse.BannerKey)
+	kermesse.BannerThumbnailUrl = media.URL(s.files, kermesse.BannerThumbnailKey)
+	kermesse.MapUrl = media.URL(s.files, kermesse.MapKey)
+	kermesse.MapThumbnailUrl = media.URL(s.files, kermesse.MapThumbnailKey)
 }
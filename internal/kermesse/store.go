@@ -2,9 +2,11 @@ package kermesse
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type KermesseStore interface {
@@ -14,11 +16,38 @@ type KermesseStore interface {
 	Create(input map[string]interface{}) error
 	Update(id int, input map[string]interface{}) error
 	AddParticipant(input map[string]interface{}) error
+	ImportFamily(input map[string]interface{}) (int, int, error)
 	CanAddStand(standId int) (bool, error)
-	AddStand(input map[string]interface{}) error
+	AddStand(input map[string]interface{}) (bool, error)
 	CanEnd(id int) (bool, error)
-	End(id int) error
-	// TODO // Stats(id int, filtres map[string]interface{}) (types.KermesseStats, error)
+	EndInteractions(id int) ([]types.InteractionClosed, error)
+	UpdateStatut(id int, statut string) error
+	FindToOpen(now time.Time) ([]types.Kermesse, error)
+	FindToClose(now time.Time) ([]types.Kermesse, error)
+	FindInvitations(id int) ([]types.KermesseInvitation, error)
+	FindInvitationByCode(code string) (types.KermesseInvitation, error)
+	CreateInvitation(input map[string]interface{}) (types.KermesseInvitation, error)
+	DeleteInvitation(id int, invitationId int) (bool, error)
+	RedeemInvitation(code string, userIds []int) error
+	UpdateBanner(id int, key string, thumbnailKey string) error
+	UpdateMap(id int, key string, thumbnailKey string) error
+	UpdateStandPosition(id int, standId int, input map[string]interface{}) (bool, error)
+	FindMapStands(id int) ([]types.KermesseMapStand, error)
+	Stats(id int, filtres map[string]interface{}) (types.KermesseStats, error)
+	FindMemberRole(id int, userId int) (string, error)
+	FindMembers(id int) ([]types.KermesseMember, error)
+	SaveMember(id int, userId int, role string) error
+	DeleteMember(id int, userId int) (bool, error)
+	Clone(id int, input map[string]interface{}) (types.KermesseClone, error)
+	Delete(id int) error
+	FindToAnonymise(before time.Time) ([]types.Kermesse, error)
+	Anonymise(id int) (int, error)
+	IsParticipant(id int, userId int) (bool, error)
+	Leaderboard(id int, groupBy string, limit int) ([]types.LeaderboardEntry, error)
+	UpdateCommission(id int, rate int) error
+	UpdateStandCommission(id int, standId int, rate *int) (bool, error)
+	FindCommissionRate(id int, standId int) (int, error)
+	FindTreasuryTransactions(id int) ([]types.TreasuryTransaction, error)
 }
 
 type Store struct {
@@ -32,14 +61,38 @@ func NewStore(db *sqlx.DB) *Store {
 }
 
 const (
-	queryFindAllKermesses = "SELECT * FROM kermesses"
-	queryFindKermesseById = "SELECT * FROM kermesses WHERE id=$1"
-	queryCreateKermesse   = "INSERT INTO kermesses (user_id, name, description) VALUES ($1, $2, $3)"
-	queryUpdateKermesse   = "UPDATE kermesses SET name=$1, description=$2 WHERE id=$3"
-	queryAddParticipant   = "INSERT INTO kermesses_users (kermesse_id, user_id) VALUES ($1, $2)"
-	queryAddStand         = "INSERT INTO kermesses_stands (kermesse_id, stand_id) VALUES ($1, $2)"
-	queryCanEnd           = "SELECT EXISTS ( SELECT 1 FROM tombolas WHERE kermesse_id = $1 AND statut = $2 ) AS is_true"
-	queryEnd              = "UPDATE kermesses SET statut=$1 WHERE id=$2"
+	queryFindAllKermesses     = "SELECT * FROM kermesses"
+	queryFindKermesseById     = "SELECT * FROM kermesses WHERE id=$1 AND deleted_at IS NULL"
+	queryCreateKermesse       = "INSERT INTO kermesses (user_id, organisation_id, name, description, starts_at, ends_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	queryUpdateKermesse       = "UPDATE kermesses SET name=$1, description=$2, starts_at=$3, ends_at=$4 WHERE id=$5"
+	queryAddParticipant       = "INSERT INTO kermesses_users (kermesse_id, user_id) VALUES ($1, $2) ON CONFLICT (kermesse_id, user_id) DO NOTHING"
+	queryAddStand             = "INSERT INTO kermesses_stands (kermesse_id, stand_id) SELECT k.id, s.id FROM kermesses k JOIN stands s ON s.organisation_id = k.organisation_id WHERE k.id=$1 AND s.id=$2 AND s.deleted_at IS NULL"
+	queryCanEnd               = "SELECT EXISTS ( SELECT 1 FROM tombolas WHERE kermesse_id = $1 AND statut = $2 ) AS is_true"
+	queryUpdateStatut         = "UPDATE kermesses SET statut=$1, archived_at = CASE WHEN $1 = 'ARCHIVED'::kermesse_statut_enum THEN NOW() ELSE archived_at END WHERE id=$2"
+	queryFindToOpen           = "SELECT * FROM kermesses WHERE statut=$1 AND starts_at <= $2 AND NOT is_template AND deleted_at IS NULL"
+	queryFindToClose          = "SELECT * FROM kermesses WHERE statut=$1 AND ends_at <= $2 AND deleted_at IS NULL"
+	queryFindToAnonymise      = "SELECT * FROM kermesses WHERE statut=$1 AND archived_at < $2 AND anonymised_at IS NULL"
+	queryDeleteKermesse       = "UPDATE kermesses SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL"
+	queryIsParticipant        = "SELECT EXISTS ( SELECT 1 FROM kermesses_users WHERE kermesse_id=$1 AND user_id=$2 ) AS is_true"
+	queryFindInvitations      = "SELECT * FROM kermesse_invitations WHERE kermesse_id=$1 ORDER BY created_at DESC"
+	queryFindInvitationByCode = "SELECT * FROM kermesse_invitations WHERE code=$1"
+	queryCreateInvitation     = "INSERT INTO kermesse_invitations (kermesse_id, code, max_uses, expires_at) VALUES ($1, $2, $3, $4) RETURNING *"
+	queryDeleteInvitation     = "DELETE FROM kermesse_invitations WHERE id=$1 AND kermesse_id=$2"
+	queryRedeemInvitation     = "UPDATE kermesse_invitations SET uses=uses+1 WHERE code=$1 AND (max_uses IS NULL OR uses < max_uses) AND (expires_at IS NULL OR expires_at > NOW()) RETURNING kermesse_id"
+	queryUpdateBanner         = "UPDATE kermesses SET banner_key=$1, banner_thumbnail_key=$2 WHERE id=$3"
+	queryUpdateMap            = "UPDATE kermesses SET map_key=$1, map_thumbnail_key=$2 WHERE id=$3"
+	queryUpdateStandPosition  = "UPDATE kermesses_stands SET pos_x=$1, pos_y=$2, zone=$3 WHERE kermesse_id=$4 AND stand_id=$5"
+	queryUpdateCommission     = "UPDATE kermesses SET commission_rate=$1 WHERE id=$2"
+	queryUpdateStandRate      = "UPDATE kermesses_stands SET commission_rate=$1 WHERE kermesse_id=$2 AND stand_id=$3"
+	queryFindCommissionRate   = "SELECT COALESCE(ks.commission_rate, k.commission_rate) FROM kermesses k JOIN kermesses_stands ks ON ks.kermesse_id = k.id AND ks.stand_id = $2 WHERE k.id=$1"
+	queryCreditTreasury       = "UPDATE kermesses SET treasury_jetons=treasury_jetons+$1 WHERE id=$2"
+	queryCreateTreasuryTx     = "INSERT INTO treasury_transactions (kermesse_id, type, amount, interaction_id, ticket_id) VALUES ($1, $2, $3, $4, $5)"
+	queryFindTreasuryTxs      = "SELECT * FROM treasury_transactions WHERE kermesse_id=$1 ORDER BY created_at DESC, id DESC"
+	queryFindMemberRole       = "SELECT role FROM kermesse_members WHERE kermesse_id=$1 AND user_id=$2"
+	querySaveMember           = "INSERT INTO kermesse_members (kermesse_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT (kermesse_id, user_id) DO UPDATE SET role = EXCLUDED.role"
+	queryDeleteMember         = "DELETE FROM kermesse_members WHERE kermesse_id=$1 AND user_id=$2 AND role <> $3"
+	queryCloneKermesse        = "INSERT INTO kermesses (user_id, organisation_id, name, description, starts_at, ends_at, is_template, commission_rate) SELECT $1, organisation_id, $2, $3, $4, $5, $6, commission_rate FROM kermesses WHERE id=$7 RETURNING *"
+	queryCloneTombola         = "INSERT INTO tombolas (kermesse_id, name, price, lot) SELECT $1, name, price, lot FROM tombolas WHERE kermesse_id=$2 AND deleted_at IS NULL"
 )
 
 func (s *Store) FindAll(filtres map[string]interface{}) ([]types.Kermesse, error) {
@@ -48,28 +101,60 @@ func (s *Store) FindAll(filtres map[string]interface{}) ([]types.Kermesse, error
 		SELECT DISTINCT
 			k.id AS id,
 			k.user_id AS user_id,
+			k.organisation_id AS organisation_id,
+			k.commission_rate AS commission_rate,
 			k.name AS name,
 			k.description AS description,
-			k.statut AS statut
+			k.statut AS statut,
+			k.starts_at AS starts_at,
+			k.ends_at AS ends_at,
+			k.is_template AS is_template,
+			k.archived_at AS archived_at,
+			k.anonymised_at AS anonymised_at,
+			k.banner_key AS banner_key,
+			k.banner_thumbnail_key AS banner_thumbnail_key,
+			k.map_key AS map_key,
+			k.map_thumbnail_key AS map_thumbnail_key
 		FROM kermesses k
 		FULL OUTER JOIN kermesses_users ku ON k.id = ku.kermesse_id
 		FULL OUTER JOIN kermesses_stands ks ON k.id = ks.kermesse_id
 		FULL OUTER JOIN stands s ON ks.stand_id = s.id
-		WHERE 1=1
+		WHERE k.deleted_at IS NULL
 	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	// Les membres de l'équipe voient aussi les kermesses qu'ils aident à gérer
+	member := func() string {
+		if filtres["member_id"] == nil {
+			return "FALSE"
+		}
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM kermesse_members km WHERE km.kermesse_id = k.id AND km.user_id = %s)", arg(filtres["member_id"]))
+	}
+	if filtres["archived"] == nil {
+		query += fmt.Sprintf(" AND k.statut <> %s", arg(types.KermesseStatutArchived))
+	}
+	if filtres["organisation_id"] != nil {
+		query += fmt.Sprintf(" AND k.organisation_id = %s", arg(filtres["organisation_id"]))
+	}
+	if filtres["is_template"] != nil {
+		query += fmt.Sprintf(" AND k.is_template = %s", arg(filtres["is_template"]))
+	}
 	if filtres["organisateur_id"] != nil {
-		query += fmt.Sprintf(" AND k.user_id = %v", filtres["organisateur_id"])
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM kermesse_members km WHERE km.kermesse_id = k.id AND km.user_id = %s)", arg(filtres["organisateur_id"]))
 	}
 	if filtres["parent_id"] != nil {
-		query += fmt.Sprintf(" AND ku.user_id = %v", filtres["parent_id"])
+		query += fmt.Sprintf(" AND (ku.user_id = %s OR %s)", arg(filtres["parent_id"]), member())
 	}
 	if filtres["child_id"] != nil {
-		query += fmt.Sprintf(" AND ku.user_id = %v", filtres["child_id"])
+		query += fmt.Sprintf(" AND ku.user_id = %s", arg(filtres["child_id"]))
 	}
 	if filtres["teneur_stand_id"] != nil {
-		query += fmt.Sprintf(" AND ks.stand_id IS NOT NULL AND s.user_id = %v", filtres["teneur_stand_id"])
+		query += fmt.Sprintf(" AND ((ks.stand_id IS NOT NULL AND s.user_id = %s) OR %s)", arg(filtres["teneur_stand_id"]), member())
 	}
-	err := s.db.Select(&kermesses, query)
+	err := s.db.Select(&kermesses, query, args...)
 
 	return kermesses, err
 }
@@ -86,6 +171,8 @@ func (s *Store) FindUsersInvite(id int) ([]types.UserBasic, error) {
 		FROM users u
 		LEFT JOIN kermesses_users ku ON u.id = ku.user_id AND ku.kermesse_id = $1
 		WHERE u.role = 'ENFANT'
+		AND u.organisation_id = (SELECT organisation_id FROM kermesses WHERE id = $1)
+		AND u.deleted_at IS NULL
 		AND ku.user_id IS NULL;
 	`
 	err := s.db.Select(&users, query, id)
@@ -100,14 +187,35 @@ func (s *Store) FindById(id int) (types.Kermesse, error) {
 	return kermesse, err
 }
 
-func (s *Store) Create(input map[string]interface{}) error {
-	_, err := s.db.Exec(queryCreateKermesse, input["user_id"], input["name"], input["description"])
+// Crée la kermesse et inscrit son créateur comme propriétaire dans l'équipe.
+func (s *Store) Create(input map[string]interface{}) (err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var id int
+	if err = tx.Get(&id, queryCreateKermesse, input["user_id"], input["organisation_id"], input["name"], input["description"], input["starts_at"], input["ends_at"]); err != nil {
+		return err
+	}
+	_, err = tx.Exec(querySaveMember, id, input["user_id"], types.KermesseMemberOwner)
 
 	return err
 }
 
 func (s *Store) Update(id int, input map[string]interface{}) error {
-	_, err := s.db.Exec(queryUpdateKermesse, input["name"], input["description"], id)
+	_, err := s.db.Exec(queryUpdateKermesse, input["name"], input["description"], input["starts_at"], input["ends_at"], id)
 
 	return err
 }
@@ -118,6 +226,55 @@ func (s *Store) AddParticipant(input map[string]interface{}) error {
 	return err
 }
 
+// Ligne d'un import de familles en une seule transaction : crée le parent (parent_id à 0)
+// et l'enfant (child_id à 0) manquants, renseigne la classe et inscrit l'enfant et son parent.
+// Renvoie les identifiants du parent et de l'enfant.
+func (s *Store) ImportFamily(input map[string]interface{}) (parentId int, childId int, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	const queryCreateUser = "INSERT INTO users (parent_id, name, email, password_hash, role, organisation_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	parentId, _ = input["parent_id"].(int)
+	if parentId == 0 {
+		err = tx.QueryRow(queryCreateUser, nil, input["parent_name"], input["parent_email"], input["parent_password"], types.UserRoleParent, input["organisation_id"]).Scan(&parentId)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	childId, _ = input["child_id"].(int)
+	if childId == 0 {
+		err = tx.QueryRow(queryCreateUser, parentId, input["child_name"], input["child_email"], input["child_password"], types.UserRoleEnfant, input["organisation_id"]).Scan(&childId)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if input["child_class"] != nil {
+		if _, err = tx.Exec("UPDATE users SET class=$1 WHERE id=$2", input["child_class"], childId); err != nil {
+			return 0, 0, err
+		}
+	}
+	for _, userId := range []int{childId, parentId} {
+		if _, err = tx.Exec(queryAddParticipant, input["kermesse_id"], userId); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return parentId, childId, nil
+}
+
 func (s *Store) CanAddStand(standId int) (bool, error) {
 	var isTrue bool
 	query := `
@@ -125,18 +282,23 @@ func (s *Store) CanAddStand(standId int) (bool, error) {
 			SELECT 1
 			FROM kermesses_stands ks
   		JOIN kermesses k ON ks.kermesse_id = k.id
-  		WHERE ks.stand_id = $1 AND k.statut = $2
+  		WHERE ks.stand_id = $1 AND k.statut NOT IN ($2, $3) AND NOT k.is_template AND k.deleted_at IS NULL
 		) AS is_associated
  	`
-	err := s.db.QueryRow(query, standId, types.KermesseStatutStarted).Scan(&isTrue)
+	err := s.db.QueryRow(query, standId, types.KermesseStatutClosed, types.KermesseStatutArchived).Scan(&isTrue)
 
 	return !isTrue, err
 }
 
-func (s *Store) AddStand(input map[string]interface{}) error {
-	_, err := s.db.Exec(queryAddStand, input["kermesse_id"], input["stand_id"])
+// Renvoie false si le stand n'existe pas dans l'école de la kermesse.
+func (s *Store) AddStand(input map[string]interface{}) (bool, error) {
+	result, err := s.db.Exec(queryAddStand, input["kermesse_id"], input["stand_id"])
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
 
-	return err
+	return rows > 0, err
 }
 
 func (s *Store) CanEnd(id int) (bool, error) {
@@ -146,8 +308,571 @@ func (s *Store) CanEnd(id int) (bool, error) {
 	return !isTrue, err
 }
 
-func (s *Store) End(id int) error {
-	_, err := s.db.Exec(queryEnd, types.KermesseStatutEnded, id)
+// Clôture toutes les activités encore ouvertes de la kermesse avec les points par défaut de leur stand.
+func (s *Store) EndInteractions(id int) ([]types.InteractionClosed, error) {
+	closed := []types.InteractionClosed{}
+	query := `
+		UPDATE interactions i
+		SET statut = $1, points = s.timeout_points
+		FROM stands s
+		WHERE i.stand_id = s.id
+		AND i.kermesse_id = $2
+		AND i.type = $3
+		AND i.statut = $4
+		RETURNING i.id, i.user_id, i.stand_id, i.kermesse_id, i.points
+	`
+	err := s.db.Select(&closed, query, types.InteractionStatutEnded, id, types.InteractionTypeActivite, types.InteractionStatutStarted)
+
+	return closed, err
+}
+
+func (s *Store) UpdateStatut(id int, statut string) error {
+	_, err := s.db.Exec(queryUpdateStatut, statut, id)
+
+	return err
+}
+
+// Kermesses publiées dont l'heure d'ouverture est passée.
+func (s *Store) FindToOpen(now time.Time) ([]types.Kermesse, error) {
+	kermesses := []types.Kermesse{}
+	err := s.db.Select(&kermesses, queryFindToOpen, types.KermesseStatutPublished, now)
+
+	return kermesses, err
+}
+
+// Kermesses ouvertes dont l'heure de clôture est passée.
+func (s *Store) FindToClose(now time.Time) ([]types.Kermesse, error) {
+	kermesses := []types.Kermesse{}
+	err := s.db.Select(&kermesses, queryFindToClose, types.KermesseStatutOpen, now)
+
+	return kermesses, err
+}
+
+func (s *Store) UpdateBanner(id int, key string, thumbnailKey string) error {
+	_, err := s.db.Exec(queryUpdateBanner, key, thumbnailKey, id)
+
+	return err
+}
+
+func (s *Store) UpdateMap(id int, key string, thumbnailKey string) error {
+	_, err := s.db.Exec(queryUpdateMap, key, thumbnailKey, id)
+
+	return err
+}
+
+func (s *Store) UpdateStandPosition(id int, standId int, input map[string]interface{}) (bool, error) {
+	result, err := s.db.Exec(queryUpdateStandPosition, input["pos_x"], input["pos_y"], input["zone"], id, standId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+
+	return rows > 0, err
+}
+
+// Stands rattachés à la kermesse avec leur emplacement et, pour les stands d'activité,
+// le nombre d'activités en cours (les ventes n'ont pas de file d'attente).
+func (s *Store) FindMapStands(id int) ([]types.KermesseMapStand, error) {
+	stands := []types.KermesseMapStand{}
+	query := `
+		SELECT
+			s.id AS stand_id,
+			s.name AS name,
+			s.type AS type,
+			s.category AS category,
+			s.stock AS stock,
+			s.capacity AS capacity,
+			ks.pos_x AS pos_x,
+			ks.pos_y AS pos_y,
+			ks.zone AS zone,
+			CASE WHEN s.type = $2 THEN (
+				SELECT COUNT(*) FROM interactions i
+				WHERE i.stand_id = s.id AND i.kermesse_id = ks.kermesse_id AND i.type = $3 AND i.statut = $4
+			) END AS queue_length
+		FROM kermesses_stands ks
+		JOIN stands s ON ks.stand_id = s.id
+		WHERE ks.kermesse_id = $1 AND s.deleted_at IS NULL
+		ORDER BY ks.zone NULLS LAST, s.name
+	`
+	err := s.db.Select(&stands, query, id, types.StandTypeActivite, types.InteractionTypeActivite, types.InteractionStatutStarted)
+
+	return stands, err
+}
+
+// Agrège l'activité de la kermesse. Les filtres restreignent le périmètre à un stand
+// (teneur_stand_id), à une famille (parent_id) et à une période (from, to).
+func (s *Store) Stats(id int, filtres map[string]interface{}) (types.KermesseStats, error) {
+	stats := types.KermesseStats{}
+	args := []interface{}{id}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	interactions := "i.kermesse_id = $1"
+	tickets := "t.kermesse_id = $1 AND t.statut <> 'CANCELLED'"
+	users := "SELECT COUNT(*) FROM kermesses_users ku WHERE ku.kermesse_id = $1"
+	stands := "SELECT COUNT(*) FROM kermesses_stands ks WHERE ks.kermesse_id = $1"
+
+	if filtres["from"] != nil {
+		from := arg(filtres["from"])
+		interactions += fmt.Sprintf(" AND i.created_at >= %s", from)
+		tickets += fmt.Sprintf(" AND tk.created_at >= %s", from)
+	}
+	if filtres["to"] != nil {
+		to := arg(filtres["to"])
+		interactions += fmt.Sprintf(" AND i.created_at < %s", to)
+		tickets += fmt.Sprintf(" AND tk.created_at < %s", to)
+	}
+	if filtres["teneur_stand_id"] != nil {
+		// Le stand ne vend pas de tickets de tombola
+		interactions += fmt.Sprintf(" AND i.stand_id IN (SELECT id FROM stands WHERE user_id = %s)", arg(filtres["teneur_stand_id"]))
+		tickets += " AND FALSE"
+		users = fmt.Sprintf("SELECT COUNT(DISTINCT i.user_id) FROM interactions i WHERE %s", interactions)
+		stands = fmt.Sprintf("SELECT COUNT(DISTINCT i.stand_id) FROM interactions i WHERE %s", interactions)
+	}
+	if filtres["parent_id"] != nil {
+		family := fmt.Sprintf("(SELECT id FROM users WHERE id = %[1]s OR parent_id = %[1]s)", arg(filtres["parent_id"]))
+		interactions += fmt.Sprintf(" AND i.user_id IN %s", family)
+		tickets += fmt.Sprintf(" AND tk.user_id IN %s", family)
+		users += fmt.Sprintf(" AND ku.user_id IN %s", family)
+		stands = fmt.Sprintf("SELECT COUNT(DISTINCT i.stand_id) FROM interactions i WHERE %s", interactions)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			(%s) AS user_count,
+			(%s) AS stand_count,
+			i.interaction_count,
+			i.interaction_income,
+			i.commission_income,
+			i.points,
+			t.ticket_count,
+			t.tombola_income
+		FROM (
+			SELECT
+				COUNT(*) AS interaction_count,
+				COALESCE(SUM(i.jetons) FILTER (WHERE i.refunded_at IS NULL), 0) AS interaction_income,
+				COALESCE(SUM(i.commission) FILTER (WHERE i.refunded_at IS NULL), 0) AS commission_income,
+				COALESCE(SUM(i.points), 0) AS points
+			FROM interactions i
+			WHERE %s
+		) i, (
+			SELECT
+				COUNT(*) AS ticket_count,
+				COALESCE(SUM(tk.price), 0) AS tombola_income
+			FROM tickets tk
+			JOIN tombolas t ON tk.tombola_id = t.id
+			WHERE %s
+		) t
+	`, users, stands, interactions, tickets)
+	err := s.db.QueryRow(query, args...).Scan(
+		&stats.UserCount,
+		&stats.StandCount,
+		&stats.InteractionCount,
+		&stats.InteractionIncome,
+		&stats.CommissionIncome,
+		&stats.PointsLadder,
+		&stats.TicketCount,
+		&stats.TombolaIncome,
+	)
+
+	return stats, err
+}
+
+func (s *Store) FindInvitations(id int) ([]types.KermesseInvitation, error) {
+	invitations := []types.KermesseInvitation{}
+	err := s.db.Select(&invitations, queryFindInvitations, id)
+
+	return invitations, err
+}
+
+func (s *Store) FindInvitationByCode(code string) (types.KermesseInvitation, error) {
+	invitation := types.KermesseInvitation{}
+	err := s.db.Get(&invitation, queryFindInvitationByCode, code)
+
+	return invitation, err
+}
+
+func (s *Store) CreateInvitation(input map[string]interface{}) (types.KermesseInvitation, error) {
+	invitation := types.KermesseInvitation{}
+	err := s.db.Get(&invitation, queryCreateInvitation, input["kermesse_id"], input["code"], input["max_uses"], input["expires_at"])
+
+	return invitation, err
+}
+
+func (s *Store) DeleteInvitation(id int, invitationId int) (bool, error) {
+	result, err := s.db.Exec(queryDeleteInvitation, invitationId, id)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+
+	return count > 0, err
+}
+
+// Consomme une utilisation du code et inscrit les utilisateurs à la kermesse, le tout ou rien.
+// Renvoie sql.ErrNoRows si le code est expiré ou épuisé.
+func (s *Store) RedeemInvitation(code string, userIds []int) (err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var kermesseId int
+	if err = tx.Get(&kermesseId, queryRedeemInvitation, code); err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		if _, err = tx.Exec(queryAddParticipant, kermesseId, userId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Renvoie sql.ErrNoRows si l'utilisateur ne fait pas partie de l'équipe de la kermesse.
+func (s *Store) FindMemberRole(id int, userId int) (string, error) {
+	var role string
+	err := s.db.Get(&role, queryFindMemberRole, id, userId)
+
+	return role, err
+}
+
+func (s *Store) FindMembers(id int) ([]types.KermesseMember, error) {
+	members := []types.KermesseMember{}
+	query := `
+		SELECT
+			km.id AS id,
+			km.kermesse_id AS kermesse_id,
+			km.role AS role,
+			km.created_at AS created_at,
+			u.id AS "user.id",
+			u.name AS "user.name",
+			u.email AS "user.email",
+			u.role AS "user.role",
+			u.jetons AS "user.jetons"
+		FROM kermesse_members km
+		JOIN users u ON km.user_id = u.id
+		WHERE km.kermesse_id = $1
+		ORDER BY km.created_at
+	`
+	err := s.db.Select(&members, query, id)
+
+	return members, err
+}
+
+func (s *Store) SaveMember(id int, userId int, role string) error {
+	_, err := s.db.Exec(querySaveMember, id, userId, role)
+
+	return err
+}
+
+// Le propriétaire ne peut pas être retiré de l'équipe.
+func (s *Store) DeleteMember(id int, userId int) (bool, error) {
+	result, err := s.db.Exec(queryDeleteMember, id, userId, types.KermesseMemberOwner)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+
+	return count > 0, err
+}
+
+// Crée une kermesse à partir de la configuration d'une autre : stands, tombola et promotions
+// propres à la kermesse, sans participants ni transactions. Les stands déjà pris par une autre
+// kermesse en cours ne sont pas rattachés à une kermesse réelle et sont renvoyés à part.
+// Les dates des promotions sont décalées de shift secondes.
+func (s *Store) Clone(id int, input map[string]interface{}) (clone types.KermesseClone, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return clone, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = tx.Get(&clone.Kermesse, queryCloneKermesse, input["user_id"], input["name"], input["description"], input["starts_at"], input["ends_at"], input["is_template"], id)
+	if err != nil {
+		return clone, err
+	}
+	if _, err = tx.Exec(querySaveMember, clone.Kermesse.Id, input["user_id"], types.KermesseMemberOwner); err != nil {
+		return clone, err
+	}
+
+	stands := `
+		INSERT INTO kermesses_stands (kermesse_id, stand_id, commission_rate)
+		SELECT $1, ks.stand_id, ks.commission_rate
+		FROM kermesses_stands ks
+		JOIN stands s ON ks.stand_id = s.id
+		WHERE ks.kermesse_id = $2
+		AND s.deleted_at IS NULL
+		AND ($3 OR NOT EXISTS (
+			SELECT 1
+			FROM kermesses_stands other
+			JOIN kermesses k ON other.kermesse_id = k.id
+			WHERE other.stand_id = ks.stand_id AND k.id <> $1 AND NOT k.is_template AND k.deleted_at IS NULL AND k.statut NOT IN ($4, $5)
+		))
+	`
+	_, err = tx.Exec(stands, clone.Kermesse.Id, id, clone.Kermesse.IsTemplate, types.KermesseStatutClosed, types.KermesseStatutArchived)
+	if err != nil {
+		return clone, err
+	}
+	skipped := `
+		SELECT ks.stand_id
+		FROM kermesses_stands ks
+		JOIN stands s ON ks.stand_id = s.id
+		WHERE ks.kermesse_id = $2
+		AND s.deleted_at IS NULL
+		AND ks.stand_id NOT IN (SELECT stand_id FROM kermesses_stands WHERE kermesse_id = $1)
+		ORDER BY ks.stand_id
+	`
+	clone.SkippedStands = []int{}
+	if err = tx.Select(&clone.SkippedStands, skipped, clone.Kermesse.Id, id); err != nil {
+		return clone, err
+	}
+
+	if _, err = tx.Exec(queryCloneTombola, clone.Kermesse.Id, id); err != nil {
+		return clone, err
+	}
+
+	promotions := `
+		INSERT INTO promotions (stand_id, kermesse_id, name, type, value, bundle_quantity, bundle_price, starts_at, ends_at)
+		SELECT
+			p.stand_id,
+			$1,
+			p.name,
+			p.type,
+			p.value,
+			p.bundle_quantity,
+			p.bundle_price,
+			p.starts_at + COALESCE(make_interval(secs => $3), INTERVAL '0'),
+			p.ends_at + COALESCE(make_interval(secs => $3), INTERVAL '0')
+		FROM promotions p
+		JOIN kermesses_stands ks ON ks.stand_id = p.stand_id AND ks.kermesse_id = $1
+		WHERE p.kermesse_id = $2
+	`
+	_, err = tx.Exec(promotions, clone.Kermesse.Id, id, input["shift"])
+
+	return clone, err
+}
+
+func (s *Store) Delete(id int) error {
+	_, err := s.db.Exec(queryDeleteKermesse, id)
+
+	return err
+}
+
+// Kermesses archivées avant la date donnée dont les données personnelles sont encore présentes.
+func (s *Store) FindToAnonymise(before time.Time) ([]types.Kermesse, error) {
+	kermesses := []types.Kermesse{}
+	err := s.db.Select(&kermesses, queryFindToAnonymise, types.KermesseStatutArchived, before)
+
+	return kermesses, err
+}
+
+// Efface les données personnelles des familles qui n'ont participé qu'à des kermesses
+// anonymisées, ainsi que les invitations et demandes de paiement de la kermesse.
+// Les activités et tickets sont conservés : les montants agrégés restent justes.
+// Renvoie le nombre d'utilisateurs anonymisés.
+func (s *Store) Anonymise(id int) (count int, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	users := `
+		UPDATE users u
+		SET name = 'Anonyme',
+			email = 'anonyme-' || u.id || '@invalid',
+			password_hash = '',
+			deleted_at = COALESCE(u.deleted_at, NOW())
+		WHERE u.id IN (SELECT user_id FROM kermesses_users WHERE kermesse_id = $1)
+		AND u.role IN ($2, $3)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM kermesses_users ku
+			JOIN kermesses k ON ku.kermesse_id = k.id
+			WHERE ku.user_id = u.id AND k.id <> $1 AND k.anonymised_at IS NULL
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM kermesse_members km
+			JOIN kermesses k ON km.kermesse_id = k.id
+			WHERE km.user_id = u.id AND k.id <> $1 AND k.anonymised_at IS NULL
+		)
+		RETURNING u.id
+	`
+	ids := []int{}
+	if err = tx.Select(&ids, users, id, types.UserRoleParent, types.UserRoleEnfant); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("DELETE FROM notifications WHERE user_id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("DELETE FROM kermesse_invitations WHERE kermesse_id = $1", id); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("DELETE FROM payment_requests WHERE kermesse_id = $1", id); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("UPDATE kermesses SET anonymised_at = NOW() WHERE id = $1", id); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+func (s *Store) IsParticipant(id int, userId int) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryIsParticipant, id, userId).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+// Classement des enfants par points d'activité, seul ou regroupé par classe ou par famille.
+// À égalité, le premier arrivé au total est devant.
+func (s *Store) Leaderboard(id int, groupBy string, limit int) ([]types.LeaderboardEntry, error) {
+	entries := []types.LeaderboardEntry{}
+	scores := `
+		WITH scores AS (
+			SELECT
+				u.id,
+				u.name,
+				u.class,
+				u.parent_id,
+				COALESCE(SUM(i.points), 0) AS points,
+				COUNT(*) AS activities,
+				MAX(i.created_at) AS reached_at
+			FROM interactions i
+			JOIN users u ON i.user_id = u.id
+			WHERE i.kermesse_id = $1 AND i.type = $2 AND i.statut = $3 AND u.role = $4
+			GROUP BY u.id
+		)
+	`
+	var query string
+	switch groupBy {
+	case types.LeaderboardGroupClass:
+		query = scores + `
+			SELECT
+				ROW_NUMBER() OVER (ORDER BY SUM(points) DESC, MAX(reached_at), class) AS rank,
+				NULL::INTEGER AS id,
+				COALESCE(class, 'Sans classe') AS name,
+				class,
+				SUM(points)::INTEGER AS points,
+				SUM(activities)::INTEGER AS activities,
+				COUNT(*) AS members
+			FROM scores
+			GROUP BY class
+			ORDER BY rank
+			LIMIT $5
+		`
+	case types.LeaderboardGroupFamily:
+		query = scores + `
+			SELECT
+				ROW_NUMBER() OVER (ORDER BY SUM(sc.points) DESC, MAX(sc.reached_at), p.id) AS rank,
+				p.id AS id,
+				p.name AS name,
+				NULL::VARCHAR AS class,
+				SUM(sc.points)::INTEGER AS points,
+				SUM(sc.activities)::INTEGER AS activities,
+				COUNT(*) AS members
+			FROM scores sc
+			JOIN users p ON sc.parent_id = p.id
+			GROUP BY p.id, p.name
+			ORDER BY rank
+			LIMIT $5
+		`
+	default:
+		query = scores + `
+			SELECT
+				ROW_NUMBER() OVER (ORDER BY points DESC, reached_at, id) AS rank,
+				id,
+				name,
+				class,
+				points,
+				activities,
+				1 AS members
+			FROM scores
+			ORDER BY rank
+			LIMIT $5
+		`
+	}
+	err := s.db.Select(&entries, query, id, types.InteractionTypeActivite, types.InteractionStatutEnded, types.UserRoleEnfant, limit)
+
+	return entries, err
+}
+
+func (s *Store) UpdateCommission(id int, rate int) error {
+	_, err := s.db.Exec(queryUpdateCommission, rate, id)
 
 	return err
 }
+
+// Renvoie false si le stand n'est pas rattaché à la kermesse.
+func (s *Store) UpdateStandCommission(id int, standId int, rate *int) (bool, error) {
+	result, err := s.db.Exec(queryUpdateStandRate, rate, id, standId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+
+	return rows > 0, err
+}
+
+// Taux du stand dans la kermesse, à défaut celui de la kermesse.
+// sql.ErrNoRows si le stand ne participe pas à la kermesse.
+func (s *Store) FindCommissionRate(id int, standId int) (int, error) {
+	var rate int
+	err := s.db.QueryRow(queryFindCommissionRate, id, standId).Scan(&rate)
+
+	return rate, err
+}
+
+// Crédite (ou débite si amount est négatif) la trésorerie dans la transaction et journalise le mouvement.
+func RecordTreasury(tx *sqlx.Tx, input map[string]interface{}) error {
+	if _, err := tx.Exec(queryCreditTreasury, input["amount"], input["kermesse_id"]); err != nil {
+		return err
+	}
+	_, err := tx.Exec(queryCreateTreasuryTx, input["kermesse_id"], input["type"], input["amount"], input["interaction_id"], input["ticket_id"])
+
+	return err
+}
+
+func (s *Store) FindTreasuryTransactions(id int) ([]types.TreasuryTransaction, error) {
+	transactions := []types.TreasuryTransaction{}
+	err := s.db.Select(&transactions, queryFindTreasuryTxs, id)
+
+	return transactions, err
+}
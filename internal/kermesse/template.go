@@ -0,0 +1,122 @@
+package kermesse
+
+import (
+	"context"
+	goErrors "errors"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/organisation"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+func (s *Service) GetTemplates(ctx context.Context) ([]types.Kermesse, error) {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return nil, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	filtres := map[string]interface{}{
+		"organisateur_id": userId,
+		"is_template":     true,
+	}
+	if err := organisation.Scope(ctx, filtres); err != nil {
+		return nil, err
+	}
+
+	kermesses, err := s.store.FindAll(filtres)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return kermesses, nil
+}
+
+// Recrée la kermesse (ou un modèle) en brouillon avec de nouvelles dates.
+func (s *Service) Clone(ctx context.Context, id int, input map[string]interface{}) (types.KermesseClone, error) {
+	return s.clone(ctx, id, input, false)
+}
+
+// Enregistre la configuration de la kermesse comme modèle réutilisable.
+func (s *Service) SaveTemplate(ctx context.Context, id int, input map[string]interface{}) (types.KermesseClone, error) {
+	return s.clone(ctx, id, input, true)
+}
+
+func (s *Service) clone(ctx context.Context, id int, input map[string]interface{}, isTemplate bool) (types.KermesseClone, error) {
+	source, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return types.KermesseClone{}, err
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return types.KermesseClone{}, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	values := map[string]interface{}{
+		"user_id":     userId,
+		"name":        source.Name,
+		"description": source.Description,
+		"is_template": isTemplate,
+	}
+	if input["name"] != nil {
+		name, ok := input["name"].(string)
+		if !ok || name == "" {
+			return types.KermesseClone{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("name doit être une chaîne de caractères non vide"),
+			}
+		}
+		values["name"] = name
+	}
+	if input["description"] != nil {
+		description, ok := input["description"].(string)
+		if !ok {
+			return types.KermesseClone{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("description doit être une chaîne de caractères"),
+			}
+		}
+		values["description"] = description
+	}
+
+	// Un modèle n'a pas de dates, une copie reçoit celles de sa nouvelle édition
+	if !isTemplate {
+		if _, ok := input["starts_at"]; ok {
+			values["starts_at"] = input["starts_at"]
+		}
+		if _, ok := input["ends_at"]; ok {
+			values["ends_at"] = input["ends_at"]
+		}
+	}
+	if err := prepareDates(values, types.Kermesse{}); err != nil {
+		return types.KermesseClone{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	// Les promotions datées suivent le décalage entre les deux éditions
+	if startsAt := values["starts_at"].(*time.Time); startsAt != nil && source.StartsAt != nil {
+		values["shift"] = startsAt.Sub(*source.StartsAt).Seconds()
+	}
+
+	clone, err := s.store.Clone(id, values)
+	if err != nil {
+		return types.KermesseClone{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return clone, nil
+}
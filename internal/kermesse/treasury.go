@@ -0,0 +1,116 @@
+package kermesse
+
+import (
+	"context"
+	goErrors "errors"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+// Taux de commission de la kermesse, appliqué aux ventes suivantes seulement.
+func (s *Service) UpdateCommission(ctx context.Context, id int, input map[string]interface{}) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+	if kermesse.IsFinished() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+
+	rate, err := parseCommissionRate(input)
+	if err != nil {
+		return err
+	}
+	if rate == nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("commission_rate est requis"),
+		}
+	}
+
+	if err := s.store.UpdateCommission(id, *rate); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// Taux propre au stand dans la kermesse. Avec commission_rate à null, celui de la kermesse s'applique de nouveau.
+func (s *Service) UpdateStandCommission(ctx context.Context, id int, standId int, input map[string]interface{}) error {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+	if kermesse.IsFinished() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse est déjà terminée"),
+		}
+	}
+
+	rate, err := parseCommissionRate(input)
+	if err != nil {
+		return err
+	}
+
+	found, err := s.store.UpdateStandCommission(id, standId, rate)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Le stand ne participe pas à cette kermesse"),
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetTreasury(ctx context.Context, id int) (types.Treasury, error) {
+	kermesse, err := Authorize(ctx, s.store, id, types.KermessePermissionManage)
+	if err != nil {
+		return types.Treasury{}, err
+	}
+
+	transactions, err := s.store.FindTreasuryTransactions(id)
+	if err != nil {
+		return types.Treasury{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return types.Treasury{
+		KermesseId:     id,
+		CommissionRate: kermesse.CommissionRate,
+		Jetons:         kermesse.TreasuryJetons,
+		Transactions:   transactions,
+	}, nil
+}
+
+func parseCommissionRate(input map[string]interface{}) (*int, error) {
+	if input["commission_rate"] == nil {
+		return nil, nil
+	}
+	rate, err := utils.GetIntFromMap(input, "commission_rate")
+	if err != nil || rate < 0 || rate > 100 {
+		return nil, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("commission_rate doit être un entier compris entre 0 et 100"),
+		}
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,118 @@
+package media
+
+import (
+	goErrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/generator"
+	"github.com/chall-goflutter-api/pkg/imaging"
+	"github.com/chall-goflutter-api/pkg/storage"
+)
+
+// Durée de validité des URLs renvoyées par l'API
+const urlTTL = time.Hour
+
+// Valide et enregistre une image et sa vignette sous le préfixe donné.
+func SaveImage(files storage.Storage, prefix string, data []byte) (key string, thumbnailKey string, err error) {
+	img, err := imaging.Prepare(data)
+	if err != nil {
+		if goErrors.Is(err, imaging.ErrUnsupportedType) {
+			return "", "", errors.CustomError{
+				Key: errors.UnsupportedMediaType,
+				Err: err,
+			}
+		}
+		if goErrors.Is(err, imaging.ErrTooLarge) || goErrors.Is(err, imaging.ErrTooManyPixels) || goErrors.Is(err, imaging.ErrInvalidImage) {
+			return "", "", errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		return "", "", errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	name, err := generator.RandomPassword(16)
+	if err != nil {
+		return "", "", errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	key = fmt.Sprintf("%s/%s.%s", prefix, name, img.Ext)
+	thumbnailKey = fmt.Sprintf("%s/%s_thumb.jpg", prefix, name)
+
+	if err := files.Put(key, img.Data); err != nil {
+		return "", "", errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if err := files.Put(thumbnailKey, img.Thumbnail); err != nil {
+		files.Delete(key)
+		return "", "", errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return key, thumbnailKey, nil
+}
+
+// Justificatifs acceptés : photo ou PDF, identifiés par leur contenu réel.
+var documentTypes = map[string]string{"image/jpeg": "jpg", "image/png": "png", "application/pdf": "pdf"}
+
+// Enregistre un justificatif (ticket de caisse, facture) tel quel, sans vignette.
+func SaveDocument(files storage.Storage, prefix string, data []byte) (string, error) {
+	ext, ok := documentTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", errors.CustomError{
+			Key: errors.UnsupportedMediaType,
+			Err: goErrors.New("Seuls les fichiers JPEG, PNG et PDF sont acceptés"),
+		}
+	}
+
+	name, err := generator.RandomPassword(16)
+	if err != nil {
+		return "", errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	key := fmt.Sprintf("%s/%s.%s", prefix, name, ext)
+	if err := files.Put(key, data); err != nil {
+		return "", errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return key, nil
+}
+
+// Supprime les anciens fichiers remplacés, sans faire échouer la requête.
+func Delete(files storage.Storage, keys ...*string) {
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		if err := files.Delete(*key); err != nil {
+			log.Printf("Suppression du fichier %s impossible : %v", *key, err)
+		}
+	}
+}
+
+func URL(files storage.Storage, key *string) *string {
+	if key == nil {
+		return nil
+	}
+	url := files.URL(*key, urlTTL)
+
+	return &url
+}
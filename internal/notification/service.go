@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"context"
+	goErrors "errors"
+	"strconv"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+type NotificationService interface {
+	GetAll(ctx context.Context, params map[string]interface{}) ([]types.Notification, error)
+	MarkRead(ctx context.Context, id int) error
+}
+
+type Service struct {
+	store NotificationStore
+}
+
+func NewService(store NotificationStore) *Service {
+	return &Service{
+		store: store,
+	}
+}
+
+func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]types.Notification, error) {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return nil, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	filters := map[string]interface{}{
+		"user_id": userId,
+	}
+	if value, ok := params["is_read"].(string); ok {
+		isRead, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		filters["is_read"] = isRead
+	}
+
+	notifications, err := s.store.FindAll(filters)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return notifications, nil
+}
+
+func (s *Service) MarkRead(ctx context.Context, id int) error {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+
+	found, err := s.store.MarkRead(id, userId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Notification non trouvée"),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"fmt"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationStore interface {
+	FindAll(filters map[string]interface{}) ([]types.Notification, error)
+	Create(input map[string]interface{}) error
+	CreateOnce(input map[string]interface{}) (bool, error)
+	MarkRead(id int, userId int) (bool, error)
+}
+
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const (
+	queryCreateNotification = "INSERT INTO notifications (user_id, type, message) VALUES ($1, $2, $3)"
+	queryCreateOnce         = "INSERT INTO notifications (user_id, type, message) SELECT $1::INTEGER, $2::VARCHAR, $3::TEXT WHERE NOT EXISTS ( SELECT 1 FROM notifications WHERE user_id = $1 AND type = $2 AND message = $3 AND is_read = FALSE )"
+	queryMarkRead           = "UPDATE notifications SET is_read=TRUE WHERE id=$1 AND user_id=$2"
+)
+
+func (s *Store) FindAll(filters map[string]interface{}) ([]types.Notification, error) {
+	notifications := []types.Notification{}
+	query := `
+		SELECT
+			n.id AS id,
+			n.user_id AS user_id,
+			n.type AS type,
+			n.message AS message,
+			n.is_read AS is_read,
+			n.created_at AS created_at
+		FROM notifications n
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filters["user_id"] != nil {
+		query += fmt.Sprintf(" AND n.user_id = %s", arg(filters["user_id"]))
+	}
+	if filters["is_read"] != nil {
+		query += fmt.Sprintf(" AND n.is_read = %s", arg(filters["is_read"]))
+	}
+	query += " ORDER BY n.created_at DESC"
+	err := s.db.Select(&notifications, query, args...)
+
+	return notifications, err
+}
+
+func (s *Store) Create(input map[string]interface{}) error {
+	_, err := s.db.Exec(queryCreateNotification, input["user_id"], input["type"], input["message"])
+
+	return err
+}
+
+// Comme Create, sans doublon tant que la notification identique n'a pas été lue.
+func (s *Store) CreateOnce(input map[string]interface{}) (bool, error) {
+	result, err := s.db.Exec(queryCreateOnce, input["user_id"], input["type"], input["message"])
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+
+	return count > 0, err
+}
+
+func (s *Store) MarkRead(id int, userId int) (bool, error) {
+	result, err := s.db.Exec(queryMarkRead, id, userId)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+
+	return count > 0, err
+}
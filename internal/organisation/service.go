@@ -0,0 +1,35 @@
+package organisation
+
+import (
+	"context"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+type OrganisationService interface {
+	GetAll(ctx context.Context) ([]types.Organisation, error)
+}
+
+type Service struct {
+	store OrganisationStore
+}
+
+func NewService(store OrganisationStore) *Service {
+	return &Service{
+		store: store,
+	}
+}
+
+// Liste publique des écoles, pour le choix à l'inscription.
+func (s *Service) GetAll(ctx context.Context) ([]types.Organisation, error) {
+	organisations, err := s.store.FindAll()
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return organisations, nil
+}
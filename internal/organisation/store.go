@@ -0,0 +1,67 @@
+package organisation
+
+import (
+	"fmt"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type OrganisationStore interface {
+	FindAll() ([]types.Organisation, error)
+	Exists(id int) (bool, error)
+	FindOwner(resource string, id int) (int, error)
+}
+
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const (
+	queryFindAllOrganisations = "SELECT * FROM organisations ORDER BY name"
+	queryExistsOrganisation   = "SELECT EXISTS ( SELECT 1 FROM organisations WHERE id=$1 ) AS is_true"
+)
+
+// École propriétaire de chaque type de ressource adressable par son id dans une URL.
+var queryFindOwner = map[string]string{
+	ResourceKermesse:    "SELECT organisation_id FROM kermesses WHERE id=$1",
+	ResourceStand:       "SELECT organisation_id FROM stands WHERE id=$1",
+	ResourceUser:        "SELECT organisation_id FROM users WHERE id=$1",
+	ResourceTombola:     "SELECT k.organisation_id FROM tombolas t JOIN kermesses k ON t.kermesse_id = k.id WHERE t.id=$1",
+	ResourceTicket:      "SELECT k.organisation_id FROM tickets ti JOIN tombolas t ON ti.tombola_id = t.id JOIN kermesses k ON t.kermesse_id = k.id WHERE ti.id=$1",
+	ResourceInteraction: "SELECT k.organisation_id FROM interactions i JOIN kermesses k ON i.kermesse_id = k.id WHERE i.id=$1",
+	ResourceShift:       "SELECT k.organisation_id FROM shifts sh JOIN kermesses k ON sh.kermesse_id = k.id WHERE sh.id=$1",
+	ResourceExpense:     "SELECT k.organisation_id FROM expenses e JOIN kermesses k ON e.kermesse_id = k.id WHERE e.id=$1",
+}
+
+func (s *Store) FindAll() ([]types.Organisation, error) {
+	organisations := []types.Organisation{}
+	err := s.db.Select(&organisations, queryFindAllOrganisations)
+
+	return organisations, err
+}
+
+func (s *Store) Exists(id int) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryExistsOrganisation, id).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+// Renvoie sql.ErrNoRows si la ressource n'existe pas.
+func (s *Store) FindOwner(resource string, id int) (int, error) {
+	query, ok := queryFindOwner[resource]
+	if !ok {
+		return 0, fmt.Errorf("ressource inconnue : %s", resource)
+	}
+	var organisationId int
+	err := s.db.Get(&organisationId, query, id)
+
+	return organisationId, err
+}
@@ -0,0 +1,73 @@
+package organisation
+
+import (
+	"context"
+	goErrors "errors"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+const (
+	ResourceKermesse    = "kermesse"
+	ResourceStand       = "stand"
+	ResourceUser        = "user"
+	ResourceTombola     = "tombola"
+	ResourceTicket      = "ticket"
+	ResourceInteraction = "interaction"
+	ResourceShift       = "shift"
+	ResourceExpense     = "expense"
+)
+
+// Ressource désignée par chaque variable de route, selon le premier segment du chemin.
+// Les autres identifiants (invitations, promotions, notifications) sont filtrés par leur parent.
+var RouteResources = map[string]map[string]string{
+	"kermesses":    {"id": ResourceKermesse, "standId": ResourceStand, "userId": ResourceUser},
+	"stands":       {"id": ResourceStand},
+	"users":        {"id": ResourceUser},
+	"tombolas":     {"id": ResourceTombola},
+	"tickets":      {"id": ResourceTicket},
+	"interactions": {"id": ResourceInteraction},
+	"shifts":       {"id": ResourceShift},
+	"expenses":     {"id": ResourceExpense},
+}
+
+// École de l'utilisateur connecté, placée dans le contexte par le middleware d'authentification.
+func FromContext(ctx context.Context) (int, error) {
+	organisationId, ok := ctx.Value(types.OrganisationIDKey).(int)
+	if !ok {
+		return 0, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("École non trouvée dans le contexte"),
+		}
+	}
+
+	return organisationId, nil
+}
+
+// Restreint les filtres d'une liste à l'école de l'utilisateur connecté.
+func Scope(ctx context.Context, filtres map[string]interface{}) error {
+	organisationId, err := FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	filtres["organisation_id"] = organisationId
+
+	return nil
+}
+
+// Une ressource d'une autre école est traitée comme inexistante.
+func Check(ctx context.Context, organisationId int) error {
+	current, err := FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if current != organisationId {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Ressource non trouvée"),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	goErrors "errors"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/interaction"
+	"github.com/chall-goflutter-api/internal/stand"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/generator"
+	"github.com/chall-goflutter-api/pkg/signature"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+const (
+	defaultExpiresIn = 120
+	maxExpiresIn     = 600
+)
+
+type PaymentService interface {
+	Create(ctx context.Context, standId int, input map[string]interface{}) (types.PaymentRequest, error)
+	Confirm(ctx context.Context, input map[string]interface{}) error
+}
+
+type Service struct {
+	store              PaymentStore
+	standStore         stand.StandStore
+	interactionService interaction.InteractionService
+	secret             string
+}
+
+func NewService(store PaymentStore, standStore stand.StandStore, interactionService interaction.InteractionService, secret string) *Service {
+	return &Service{
+		store:              store,
+		standStore:         standStore,
+		interactionService: interactionService,
+		secret:             secret,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, standId int, input map[string]interface{}) (types.PaymentRequest, error) {
+	stand, err := s.standStore.FindById(standId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return types.PaymentRequest{}, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	kermesseId, err := utils.GetIntFromMap(input, "kermesse_id")
+	if err != nil {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	attached, err := s.standStore.IsAttached(standId, kermesseId)
+	if err != nil {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !attached {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le stand ne participe pas à cette kermesse"),
+		}
+	}
+	quantity := 1
+	if stand.Type == types.StandTypeVente {
+		quantity, err = utils.GetIntFromMap(input, "quantity")
+		if err != nil {
+			return types.PaymentRequest{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		if quantity <= 0 || quantity > stand.Stock {
+			return types.PaymentRequest{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Quantité invalide"),
+			}
+		}
+	}
+	expiresIn := defaultExpiresIn
+	if input["expires_in"] != nil {
+		expiresIn, err = utils.GetIntFromMap(input, "expires_in")
+		if err != nil || expiresIn <= 0 || expiresIn > maxExpiresIn {
+			return types.PaymentRequest{}, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Durée de validité invalide"),
+			}
+		}
+	}
+
+	// Le montant affiché est calculé comme à l'achat, promotions comprises
+	promotions, err := s.standStore.FindActivePromotions(standId, kermesseId)
+	if err != nil {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	amount, _ := types.BestPromotion(stand.Price, quantity, promotions)
+
+	nonce, err := generator.RandomPassword(32)
+	if err != nil {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	request, err := s.store.Create(map[string]interface{}{
+		"stand_id":    standId,
+		"kermesse_id": kermesseId,
+		"user_id":     userId,
+		"quantity":    quantity,
+		"amount":      amount,
+		"nonce":       nonce,
+		"expires_at":  time.Now().Add(time.Duration(expiresIn) * time.Second),
+	})
+	if err != nil {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	request.Payload, err = encodePayload(s.secret, request)
+	if err != nil {
+		return types.PaymentRequest{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return request, nil
+}
+
+func (s *Service) Confirm(ctx context.Context, input map[string]interface{}) error {
+	payload, ok := input["payload"].(string)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("payload est manquant ou n'est pas une chaîne de caractères"),
+		}
+	}
+	claims, err := decodePayload(s.secret, payload)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La demande de paiement a expiré"),
+		}
+	}
+
+	request, err := s.store.FindById(claims.Id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if request.Nonce != claims.Nonce {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Demande de paiement invalide"),
+		}
+	}
+
+	// La demande est marquée utilisée dans la transaction de l'achat : un achat refusé
+	// ou interrompu la laisse disponible
+	return s.interactionService.CreateFromRequest(ctx, request.Id, map[string]interface{}{
+		"stand_id":        float64(request.StandId),
+		"kermesse_id":     float64(request.KermesseId),
+		"quantity":        float64(request.Quantity),
+		"expected_jetons": request.Amount,
+	})
+}
+
+// Encode la demande en "<claims en base64>.<signature>" pour le QR code.
+func encodePayload(secret string, request types.PaymentRequest) (string, error) {
+	claims, err := json.Marshal(types.PaymentRequestClaims{
+		Id:         request.Id,
+		StandId:    request.StandId,
+		KermesseId: request.KermesseId,
+		Quantity:   request.Quantity,
+		Amount:     request.Amount,
+		Nonce:      request.Nonce,
+		ExpiresAt:  request.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(claims)
+
+	return encoded + "." + signature.Sign(secret, []byte(encoded)), nil
+}
+
+func decodePayload(secret string, payload string) (types.PaymentRequestClaims, error) {
+	claims := types.PaymentRequestClaims{}
+	parts := strings.Split(payload, ".")
+	if len(parts) != 2 || !signature.Verify(secret, []byte(parts[0]), parts[1]) {
+		return claims, goErrors.New("Signature de la demande de paiement invalide")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, err
+	}
+	err = json.Unmarshal(raw, &claims)
+
+	return claims, err
+}
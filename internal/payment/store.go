@@ -0,0 +1,40 @@
+package payment
+
+import (
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type PaymentStore interface {
+	FindById(id int) (types.PaymentRequest, error)
+	Create(input map[string]interface{}) (types.PaymentRequest, error)
+}
+
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const (
+	queryFindPaymentRequestById = "SELECT * FROM payment_requests WHERE id=$1"
+	queryCreatePaymentRequest   = "INSERT INTO payment_requests (stand_id, kermesse_id, user_id, quantity, amount, nonce, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *"
+)
+
+func (s *Store) FindById(id int) (types.PaymentRequest, error) {
+	request := types.PaymentRequest{}
+	err := s.db.Get(&request, queryFindPaymentRequestById, id)
+
+	return request, err
+}
+
+func (s *Store) Create(input map[string]interface{}) (types.PaymentRequest, error) {
+	request := types.PaymentRequest{}
+	err := s.db.Get(&request, queryCreatePaymentRequest, input["stand_id"], input["kermesse_id"], input["user_id"], input["quantity"], input["amount"], input["nonce"], input["expires_at"])
+
+	return request, err
+}
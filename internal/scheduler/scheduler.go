@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+// Tâche planifiée, elle renvoie un résumé de ce qu'elle a fait.
+type JobFunc func(ctx context.Context) (string, error)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// Exécute les tâches de fond de l'API à intervalle régulier.
+type Scheduler struct {
+	store SchedulerStore
+	jobs  []job
+}
+
+func NewScheduler(store SchedulerStore) *Scheduler {
+	return &Scheduler{
+		store: store,
+	}
+}
+
+// Un intervalle nul ou négatif ferait paniquer le ticker, la tâche est alors refusée.
+func (s *Scheduler) Register(name string, interval time.Duration, run JobFunc) error {
+	if interval <= 0 {
+		return fmt.Errorf("Intervalle invalide pour la tâche %s : %v", name, interval)
+	}
+	s.jobs = append(s.jobs, job{
+		name:     name,
+		interval: interval,
+		run:      run,
+	})
+
+	return nil
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, j := range s.jobs {
+		go s.loop(ctx, j)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.execute(ctx, j)
+		}
+	}
+}
+
+// Exécute la tâche si aucune autre instance ne l'exécute déjà, et enregistre le résultat.
+func (s *Scheduler) execute(ctx context.Context, j job) {
+	conn, locked, err := s.store.Lock(ctx, j.name)
+	if err != nil {
+		log.Printf("Error locking job %s: %v", j.name, err)
+		return
+	}
+	if !locked {
+		return
+	}
+	defer func() {
+		if err := s.store.Unlock(context.Background(), conn, j.name); err != nil {
+			log.Printf("Error unlocking job %s: %v", j.name, err)
+		}
+	}()
+
+	startedAt := time.Now()
+	message, err := s.safeRun(ctx, j)
+	statut := types.JobRunStatutSuccess
+	if err != nil {
+		statut = types.JobRunStatutFailed
+		message = err.Error()
+		log.Printf("Job %s failed: %v", j.name, err)
+	}
+
+	// Les exécutions sans effet ne sont pas conservées pour ne pas noyer l'historique
+	if statut == types.JobRunStatutSuccess && message == "" {
+		return
+	}
+	err = s.store.CreateRun(map[string]interface{}{
+		"name":        j.name,
+		"statut":      statut,
+		"message":     message,
+		"started_at":  startedAt,
+		"finished_at": time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error saving run of job %s: %v", j.name, err)
+	}
+}
+
+func (s *Scheduler) safeRun(ctx context.Context, j job) (message string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return j.run(ctx)
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	goErrors "errors"
+	"strconv"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+const (
+	defaultRunsLimit = 50
+	maxRunsLimit     = 200
+)
+
+type SchedulerService interface {
+	GetRuns(ctx context.Context, params map[string]interface{}) ([]types.JobRun, error)
+}
+
+type Service struct {
+	store SchedulerStore
+}
+
+func NewService(store SchedulerStore) *Service {
+	return &Service{
+		store: store,
+	}
+}
+
+func (s *Service) GetRuns(ctx context.Context, params map[string]interface{}) ([]types.JobRun, error) {
+	filtres := map[string]interface{}{
+		"limit": defaultRunsLimit,
+	}
+	if name, ok := params["name"].(string); ok {
+		filtres["name"] = name
+	}
+	if statut, ok := params["statut"].(string); ok {
+		if statut != types.JobRunStatutSuccess && statut != types.JobRunStatutFailed {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Statut invalide"),
+			}
+		}
+		filtres["statut"] = statut
+	}
+	if value, ok := params["limit"].(string); ok {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxRunsLimit {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Limite invalide"),
+			}
+		}
+		filtres["limit"] = limit
+	}
+
+	runs, err := s.store.FindRuns(filtres)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type SchedulerStore interface {
+	Lock(ctx context.Context, name string) (*sqlx.Conn, bool, error)
+	Unlock(ctx context.Context, conn *sqlx.Conn, name string) error
+	CreateRun(input map[string]interface{}) error
+	FindRuns(filtres map[string]interface{}) ([]types.JobRun, error)
+}
+
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const (
+	queryTryLock   = "SELECT pg_try_advisory_lock(hashtext($1))"
+	queryUnlock    = "SELECT pg_advisory_unlock(hashtext($1))"
+	queryCreateRun = "INSERT INTO job_runs (name, statut, message, started_at, finished_at) VALUES ($1, $2, $3, $4, $5)"
+)
+
+// Prend le verrou consultatif de la tâche sur une connexion dédiée, le verrou étant lié à la session.
+// Une seule instance de l'API exécute donc la tâche à un instant donné.
+func (s *Store) Lock(ctx context.Context, name string) (*sqlx.Conn, bool, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowxContext(ctx, queryTryLock, name).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return conn, true, nil
+}
+
+func (s *Store) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	defer conn.Close()
+	_, err := conn.ExecContext(ctx, queryUnlock, name)
+
+	return err
+}
+
+func (s *Store) CreateRun(input map[string]interface{}) error {
+	_, err := s.db.Exec(queryCreateRun, input["name"], input["statut"], input["message"], input["started_at"], input["finished_at"])
+
+	return err
+}
+
+func (s *Store) FindRuns(filtres map[string]interface{}) ([]types.JobRun, error) {
+	runs := []types.JobRun{}
+	args := []interface{}{}
+	query := "SELECT * FROM job_runs WHERE 1=1"
+	if filtres["name"] != nil {
+		args = append(args, filtres["name"])
+		query += fmt.Sprintf(" AND name = $%d", len(args))
+	}
+	if filtres["statut"] != nil {
+		args = append(args, filtres["statut"])
+		query += fmt.Sprintf(" AND statut = $%d", len(args))
+	}
+	args = append(args, filtres["limit"])
+	query += fmt.Sprintf(" ORDER BY started_at DESC LIMIT $%d", len(args))
+	err := s.db.Select(&runs, query, args...)
+
+	return runs, err
+}
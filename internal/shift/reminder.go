@@ -0,0 +1,44 @@
+package shift
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+// Tâche planifiée : prévient les bénévoles dont le créneau commence dans moins de beforeMinutes minutes.
+// Le rappel in-app fait foi, l'email est envoyé en plus et son échec est seulement journalisé.
+func (s *Service) SendReminders(ctx context.Context, beforeMinutes int) (string, error) {
+	reminders, err := s.store.FindToRemind(time.Now().Add(time.Duration(beforeMinutes) * time.Minute))
+	if err != nil || len(reminders) == 0 {
+		return "", err
+	}
+
+	sent := 0
+	for _, reminder := range reminders {
+		slot := fmt.Sprintf("%s de %s à %s", reminder.StartsAt.Local().Format("02/01"), reminder.StartsAt.Local().Format("15h04"), reminder.EndsAt.Local().Format("15h04"))
+		message := fmt.Sprintf("Rappel : vous tenez le stand %s à la kermesse %s le %s", reminder.StandName, reminder.KermesseName, slot)
+
+		err := s.notificationStore.Create(map[string]interface{}{
+			"user_id": reminder.UserId,
+			"type":    types.NotificationTypeShiftReminder,
+			"message": message,
+		})
+		if err != nil {
+			return fmt.Sprintf("Rappels envoyés : %d", sent), err
+		}
+		body := fmt.Sprintf("Bonjour %s,\n\n%s.\n\nMerci pour votre aide !\n", reminder.UserName, message)
+		if err := s.mailer.Send(reminder.UserEmail, fmt.Sprintf("Votre créneau à la kermesse %s", reminder.KermesseName), body); err != nil {
+			log.Printf("Error sending shift reminder to %s: %v", reminder.UserEmail, err)
+		}
+		if err := s.store.MarkReminded(reminder.Id); err != nil {
+			return fmt.Sprintf("Rappels envoyés : %d", sent), err
+		}
+		sent++
+	}
+
+	return fmt.Sprintf("Rappels envoyés : %d", sent), nil
+}
@@ -0,0 +1,356 @@
+package shift
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/notification"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/mailer"
+	"github.com/chall-goflutter-api/pkg/utils"
+)
+
+type ShiftService interface {
+	GetAll(ctx context.Context, kermesseId int, params map[string]interface{}) ([]types.Shift, error)
+	Create(ctx context.Context, kermesseId int, input map[string]interface{}) error
+	Delete(ctx context.Context, id int) error
+	SignUp(ctx context.Context, id int) error
+	Cancel(ctx context.Context, id int) error
+	SendReminders(ctx context.Context, beforeMinutes int) (string, error)
+}
+
+type Service struct {
+	store             ShiftStore
+	kermesseStore     kermesse.KermesseStore
+	notificationStore notification.NotificationStore
+	mailer            mailer.Mailer
+}
+
+func NewService(store ShiftStore, kermesseStore kermesse.KermesseStore, notificationStore notification.NotificationStore, mailer mailer.Mailer) *Service {
+	return &Service{
+		store:             store,
+		kermesseStore:     kermesseStore,
+		notificationStore: notificationStore,
+		mailer:            mailer,
+	}
+}
+
+// Créneaux de la kermesse. Seule l'équipe voit les bénévoles inscrits,
+// les parents voient les places libres et leurs propres inscriptions.
+func (s *Service) GetAll(ctx context.Context, kermesseId int, params map[string]interface{}) ([]types.Shift, error) {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return nil, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if _, err := s.kermesseStore.FindById(kermesseId); err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	isMember, err := kermesse.Can(s.kermesseStore, kermesseId, userId, types.KermessePermissionView)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !isMember {
+		isParticipant, err := s.kermesseStore.IsParticipant(kermesseId, userId)
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !isParticipant {
+			return nil, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interdit"),
+			}
+		}
+	}
+
+	filters := map[string]interface{}{
+		"kermesse_id": kermesseId,
+	}
+	if params["stand_id"] != nil {
+		filters["stand_id"] = params["stand_id"]
+	}
+	if params["open"] == "true" {
+		filters["open"] = true
+	}
+	if params["mine"] == "true" {
+		filters["user_id"] = userId
+	}
+
+	shifts, err := s.store.FindAll(filters)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	volunteers, err := s.store.FindVolunteers(kermesseId)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	byShift := map[int][]types.ShiftVolunteer{}
+	for _, volunteer := range volunteers {
+		byShift[volunteer.ShiftId] = append(byShift[volunteer.ShiftId], volunteer)
+	}
+	for i, shift := range shifts {
+		shifts[i].Missing = max(shift.Headcount-shift.Filled, 0)
+		for _, volunteer := range byShift[shift.Id] {
+			if volunteer.User.Id == userId {
+				shifts[i].SignedUp = true
+			}
+		}
+		if isMember {
+			shifts[i].Volunteers = byShift[shift.Id]
+			if shifts[i].Volunteers == nil {
+				shifts[i].Volunteers = []types.ShiftVolunteer{}
+			}
+		}
+	}
+
+	return shifts, nil
+}
+
+func (s *Service) Create(ctx context.Context, kermesseId int, input map[string]interface{}) error {
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+	if kermesse.IsFinished() || kermesse.IsTemplate {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Impossible de planifier des créneaux sur cette kermesse"),
+		}
+	}
+
+	standId, err := utils.GetIntFromMap(input, "stand_id")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	headcount, err := utils.GetIntFromMap(input, "headcount")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if headcount <= 0 {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Il faut au moins un bénévole par créneau"),
+		}
+	}
+	startsAt, err := utils.GetTimeFromMap(input, "starts_at")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	endsAt, err := utils.GetTimeFromMap(input, "ends_at")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if startsAt == nil || endsAt == nil || !endsAt.After(*startsAt) {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le créneau doit avoir un début et une fin postérieure au début"),
+		}
+	}
+
+	attached, err := s.store.IsStandAttached(kermesseId, standId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !attached {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le stand ne participe pas à cette kermesse"),
+		}
+	}
+
+	_, err = s.store.Create(map[string]interface{}{
+		"kermesse_id": kermesseId,
+		"stand_id":    standId,
+		"starts_at":   *startsAt,
+		"ends_at":     *endsAt,
+		"headcount":   headcount,
+	})
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int) error {
+	shift, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, shift.KermesseId, types.KermessePermissionManage); err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(id); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// Inscrit le parent sur un créneau à venir de sa kermesse, sans chevauchement avec ses autres créneaux.
+func (s *Service) SignUp(ctx context.Context, id int) error {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	shift, err := s.find(id)
+	if err != nil {
+		return err
+	}
+
+	isParticipant, err := s.kermesseStore.IsParticipant(shift.KermesseId, userId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !isParticipant {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+	if !shift.StartsAt.After(time.Now()) {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le créneau a déjà commencé"),
+		}
+	}
+
+	overlap, err := s.store.HasOverlap(userId, shift.StartsAt, shift.EndsAt)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if overlap {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Vous êtes déjà inscrit sur un créneau à ce moment-là"),
+		}
+	}
+
+	ok, err = s.store.SignUp(id, userId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !ok {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le créneau est complet"),
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Cancel(ctx context.Context, id int) error {
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	shift, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if !shift.StartsAt.After(time.Now()) {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le créneau a déjà commencé"),
+		}
+	}
+
+	found, err := s.store.Cancel(id, userId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Vous n'êtes pas inscrit sur ce créneau"),
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) find(id int) (types.Shift, error) {
+	shift, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return shift, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return shift, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return shift, nil
+}
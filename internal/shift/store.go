@@ -0,0 +1,251 @@
+package shift
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type ShiftStore interface {
+	FindAll(filters map[string]interface{}) ([]types.Shift, error)
+	FindById(id int) (types.Shift, error)
+	FindVolunteers(kermesseId int) ([]types.ShiftVolunteer, error)
+	IsStandAttached(kermesseId int, standId int) (bool, error)
+	Create(input map[string]interface{}) (int, error)
+	Delete(id int) error
+	HasOverlap(userId int, startsAt time.Time, endsAt time.Time) (bool, error)
+	SignUp(id int, userId int) (bool, error)
+	Cancel(id int, userId int) (bool, error)
+	IsVolunteer(standId int, userId int) (bool, error)
+	IsOnShift(standId int, userId int, at time.Time) (bool, error)
+	FindToRemind(before time.Time) ([]types.ShiftReminder, error)
+	MarkReminded(id int) error
+}
+
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const (
+	queryCreateShift     = "INSERT INTO shifts (kermesse_id, stand_id, starts_at, ends_at, headcount) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	queryDeleteShift     = "DELETE FROM shifts WHERE id=$1"
+	queryIsStandAttached = "SELECT EXISTS ( SELECT 1 FROM kermesses_stands WHERE kermesse_id=$1 AND stand_id=$2 ) AS is_true"
+	queryLockHeadcount   = "SELECT headcount FROM shifts WHERE id=$1 FOR UPDATE"
+	queryCountVolunteers = "SELECT COUNT(*) FROM shift_volunteers WHERE shift_id=$1"
+	querySignUp          = "INSERT INTO shift_volunteers (shift_id, user_id) VALUES ($1, $2) ON CONFLICT (shift_id, user_id) DO NOTHING"
+	queryCancel          = "DELETE FROM shift_volunteers WHERE shift_id=$1 AND user_id=$2"
+	queryIsVolunteer     = "SELECT EXISTS ( SELECT 1 FROM shift_volunteers sv JOIN shifts sh ON sv.shift_id = sh.id WHERE sh.stand_id=$1 AND sv.user_id=$2 ) AS is_true"
+	queryIsOnShift       = "SELECT EXISTS ( SELECT 1 FROM shift_volunteers sv JOIN shifts sh ON sv.shift_id = sh.id WHERE sh.stand_id=$1 AND sv.user_id=$2 AND sh.starts_at <= $3 AND sh.ends_at > $3 ) AS is_true"
+	queryMarkReminded    = "UPDATE shift_volunteers SET reminded_at=NOW() WHERE id=$1"
+)
+
+const selectShifts = `
+	SELECT
+		sh.id AS id,
+		sh.kermesse_id AS kermesse_id,
+		sh.stand_id AS stand_id,
+		s.name AS stand_name,
+		sh.starts_at AS starts_at,
+		sh.ends_at AS ends_at,
+		sh.headcount AS headcount,
+		(SELECT COUNT(*) FROM shift_volunteers sv WHERE sv.shift_id = sh.id) AS filled
+	FROM shifts sh
+	JOIN stands s ON sh.stand_id = s.id
+`
+
+func (s *Store) FindAll(filters map[string]interface{}) ([]types.Shift, error) {
+	shifts := []types.Shift{}
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := selectShifts + " WHERE 1=1"
+	if filters["kermesse_id"] != nil {
+		query += fmt.Sprintf(" AND sh.kermesse_id = %s", arg(filters["kermesse_id"]))
+	}
+	if filters["stand_id"] != nil {
+		query += fmt.Sprintf(" AND sh.stand_id = %s", arg(filters["stand_id"]))
+	}
+	if filters["user_id"] != nil {
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM shift_volunteers sv WHERE sv.shift_id = sh.id AND sv.user_id = %s)", arg(filters["user_id"]))
+	}
+	if filters["open"] != nil {
+		// Créneaux à venir où il manque encore des bénévoles
+		query += " AND sh.ends_at > NOW() AND (SELECT COUNT(*) FROM shift_volunteers sv WHERE sv.shift_id = sh.id) < sh.headcount"
+	}
+	query += " ORDER BY sh.starts_at, s.name"
+	err := s.db.Select(&shifts, query, args...)
+
+	return shifts, err
+}
+
+func (s *Store) FindById(id int) (types.Shift, error) {
+	shift := types.Shift{}
+	err := s.db.Get(&shift, selectShifts+" WHERE sh.id = $1", id)
+
+	return shift, err
+}
+
+// Bénévoles inscrits sur tous les créneaux de la kermesse.
+func (s *Store) FindVolunteers(kermesseId int) ([]types.ShiftVolunteer, error) {
+	volunteers := []types.ShiftVolunteer{}
+	query := `
+		SELECT
+			sv.shift_id AS shift_id,
+			sv.created_at AS created_at,
+			u.id AS "user.id",
+			u.name AS "user.name",
+			u.email AS "user.email",
+			u.role AS "user.role",
+			u.jetons AS "user.jetons"
+		FROM shift_volunteers sv
+		JOIN shifts sh ON sv.shift_id = sh.id
+		JOIN users u ON sv.user_id = u.id
+		WHERE sh.kermesse_id = $1
+		ORDER BY sv.created_at
+	`
+	err := s.db.Select(&volunteers, query, kermesseId)
+
+	return volunteers, err
+}
+
+func (s *Store) IsStandAttached(kermesseId int, standId int) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryIsStandAttached, kermesseId, standId).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+func (s *Store) Create(input map[string]interface{}) (int, error) {
+	var id int
+	err := s.db.Get(&id, queryCreateShift, input["kermesse_id"], input["stand_id"], input["starts_at"], input["ends_at"], input["headcount"])
+
+	return id, err
+}
+
+func (s *Store) Delete(id int) error {
+	_, err := s.db.Exec(queryDeleteShift, id)
+
+	return err
+}
+
+// Indique si le bénévole est déjà inscrit sur un créneau qui chevauche la période donnée.
+func (s *Store) HasOverlap(userId int, startsAt time.Time, endsAt time.Time) (bool, error) {
+	var isTrue bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM shift_volunteers sv
+			JOIN shifts sh ON sv.shift_id = sh.id
+			WHERE sv.user_id = $1 AND sh.starts_at < $3 AND sh.ends_at > $2
+		) AS is_true
+	`
+	err := s.db.QueryRow(query, userId, startsAt, endsAt).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+// Inscrit le bénévole si le créneau n'est pas complet. Renvoie false s'il l'est.
+func (s *Store) SignUp(id int, userId int) (ok bool, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// Le verrou sur le créneau sérialise les inscriptions simultanées
+	var headcount, filled int
+	if err = tx.Get(&headcount, queryLockHeadcount, id); err != nil {
+		return false, err
+	}
+	if err = tx.Get(&filled, queryCountVolunteers, id); err != nil {
+		return false, err
+	}
+	if filled >= headcount {
+		return false, nil
+	}
+	if _, err = tx.Exec(querySignUp, id, userId); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *Store) Cancel(id int, userId int) (bool, error) {
+	result, err := s.db.Exec(queryCancel, id, userId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+
+	return rows > 0, err
+}
+
+func (s *Store) IsVolunteer(standId int, userId int) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryIsVolunteer, standId, userId).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+func (s *Store) IsOnShift(standId int, userId int, at time.Time) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryIsOnShift, standId, userId, at).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+// Inscriptions dont le créneau commence avant la date donnée et dont le rappel n'est pas encore parti.
+func (s *Store) FindToRemind(before time.Time) ([]types.ShiftReminder, error) {
+	reminders := []types.ShiftReminder{}
+	query := `
+		SELECT
+			sv.id AS id,
+			u.id AS user_id,
+			u.name AS user_name,
+			u.email AS user_email,
+			k.name AS kermesse_name,
+			s.name AS stand_name,
+			sh.starts_at AS starts_at,
+			sh.ends_at AS ends_at
+		FROM shift_volunteers sv
+		JOIN shifts sh ON sv.shift_id = sh.id
+		JOIN stands s ON sh.stand_id = s.id
+		JOIN kermesses k ON sh.kermesse_id = k.id
+		JOIN users u ON sv.user_id = u.id
+		WHERE sv.reminded_at IS NULL
+		AND sh.starts_at > NOW() AND sh.starts_at <= $1
+		AND k.deleted_at IS NULL AND k.statut NOT IN ($2, $3)
+		AND u.deleted_at IS NULL
+		ORDER BY sh.starts_at
+	`
+	err := s.db.Select(&reminders, query, before, types.KermesseStatutClosed, types.KermesseStatutArchived)
+
+	return reminders, err
+}
+
+func (s *Store) MarkReminded(id int) error {
+	_, err := s.db.Exec(queryMarkReminded, id)
+
+	return err
+}
@@ -0,0 +1,169 @@
+package stand
+
+import (
+	goErrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/lib/pq"
+)
+
+const (
+	maxTags          = 10
+	maxTagLength     = 30
+	defaultSearchMax = 20
+	maxSearchLimit   = 100
+)
+
+var categories = map[string]bool{
+	types.StandCategoryNourriture: true,
+	types.StandCategoryBoisson:    true,
+	types.StandCategoryJeu:        true,
+	types.StandCategoryAtelier:    true,
+	types.StandCategoryArtisanat:  true,
+	types.StandCategoryAutre:      true,
+}
+
+// Valide la catégorie, les tags et le nom du produit, en conservant les valeurs actuelles
+// de ceux qui ne sont pas fournis.
+func prepareCatalogue(input map[string]interface{}, current types.Stand) error {
+	if value, ok := input["category"]; !ok {
+		input["category"] = current.Category
+	} else if value != nil {
+		category, ok := value.(string)
+		if !ok || !categories[category] {
+			return fmt.Errorf("Catégorie inconnue : %v", value)
+		}
+	}
+
+	if value, ok := input["tags"]; !ok {
+		input["tags"] = current.Tags
+		if current.Tags == nil {
+			input["tags"] = pq.StringArray{}
+		}
+	} else {
+		values, ok := value.([]interface{})
+		if value != nil && !ok {
+			return goErrors.New("tags doit être une liste")
+		}
+		tags := []string{}
+		for _, v := range values {
+			tag, ok := v.(string)
+			if !ok {
+				return goErrors.New("Les tags doivent être des chaînes de caractères")
+			}
+			tags = append(tags, tag)
+		}
+		normalized, err := normalizeTags(tags)
+		if err != nil {
+			return err
+		}
+		input["tags"] = normalized
+	}
+
+	if value, ok := input["product_name"]; !ok {
+		input["product_name"] = current.ProductName
+	} else if value != nil {
+		if current.Type != types.StandTypeVente {
+			return goErrors.New("Seuls les stands de vente ont un produit")
+		}
+		name, ok := value.(string)
+		if !ok || len(name) > 255 {
+			return goErrors.New("Nom de produit invalide")
+		}
+	}
+
+	return nil
+}
+
+// Met les tags en minuscules, sans espaces superflus ni doublons.
+func normalizeTags(tags []string) (pq.StringArray, error) {
+	normalized := pq.StringArray{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("Le tag %s dépasse %d caractères", tag, maxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("Un stand ne peut pas avoir plus de %d tags", maxTags)
+	}
+
+	return normalized, nil
+}
+
+// Convertit les paramètres de la requête de recherche en filtres pour le store.
+func prepareSearch(params map[string]interface{}) (map[string]interface{}, error) {
+	filtres := map[string]interface{}{
+		"limit": defaultSearchMax,
+	}
+
+	if q, ok := params["q"].(string); ok && strings.TrimSpace(q) != "" {
+		filtres["q"] = strings.TrimSpace(q)
+	}
+	if standType, ok := params["type"].(string); ok {
+		if standType != types.StandTypeVente && standType != types.StandTypeActivite {
+			return nil, fmt.Errorf("Type de stand inconnu : %s", standType)
+		}
+		filtres["type"] = standType
+	}
+	if category, ok := params["category"].(string); ok {
+		if !categories[category] {
+			return nil, fmt.Errorf("Catégorie inconnue : %s", category)
+		}
+		filtres["category"] = category
+	}
+
+	// tags=a,b ou tags=a&tags=b
+	var tags []string
+	switch value := params["tags"].(type) {
+	case string:
+		tags = strings.Split(value, ",")
+	case []string:
+		tags = value
+	}
+	if len(tags) > 0 {
+		normalized, err := normalizeTags(tags)
+		if err != nil {
+			return nil, err
+		}
+		if len(normalized) > 0 {
+			filtres["tags"] = normalized
+		}
+	}
+
+	for _, key := range []string{"min_price", "max_price", "kermesse_id", "limit"} {
+		value, ok := params[key].(string)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s n'est pas un nombre valide", key)
+		}
+		filtres[key] = n
+	}
+	if filtres["limit"].(int) == 0 || filtres["limit"].(int) > maxSearchLimit {
+		return nil, fmt.Errorf("limit doit être compris entre 1 et %d", maxSearchLimit)
+	}
+
+	if available, ok := params["available"].(string); ok {
+		isAvailable, err := strconv.ParseBool(available)
+		if err != nil {
+			return nil, goErrors.New("available doit être un booléen")
+		}
+		if isAvailable {
+			filtres["available"] = true
+		}
+	}
+
+	return filtres, nil
+}
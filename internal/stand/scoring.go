@@ -0,0 +1,61 @@
+package stand
+
+import (
+	"encoding/json"
+	goErrors "errors"
+	"fmt"
+	"sort"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+// Convertit la valeur "scoring" reçue en JSON en barème validé.
+func ParseScoring(value interface{}) (*types.StandScoring, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	scoring := types.StandScoring{}
+	if err := json.Unmarshal(raw, &scoring); err != nil {
+		return nil, goErrors.New("Barème invalide")
+	}
+
+	switch scoring.Mode {
+	case types.StandScoringRange:
+		if scoring.Min < 0 || scoring.Max < scoring.Min {
+			return nil, goErrors.New("Le barème doit avoir 0 <= min <= max")
+		}
+	case types.StandScoringTiers:
+		if len(scoring.Tiers) == 0 {
+			return nil, goErrors.New("Le barème doit contenir au moins un palier")
+		}
+		for tier, points := range scoring.Tiers {
+			if points < 0 {
+				return nil, fmt.Errorf("Points négatifs pour le palier %s", tier)
+			}
+		}
+	case types.StandScoringMapping:
+		if len(scoring.Mapping) == 0 {
+			return nil, goErrors.New("Le barème doit contenir au moins une correspondance")
+		}
+		sort.Slice(scoring.Mapping, func(i, j int) bool {
+			return scoring.Mapping[i].Score < scoring.Mapping[j].Score
+		})
+		for i, step := range scoring.Mapping {
+			if step.Points < 0 {
+				return nil, fmt.Errorf("Points négatifs pour le score %d", step.Score)
+			}
+			if i > 0 && scoring.Mapping[i-1].Score == step.Score {
+				return nil, fmt.Errorf("Score %d défini plusieurs fois", step.Score)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Mode de barème inconnu : %v", scoring.Mode)
+	}
+
+	return &scoring, nil
+}
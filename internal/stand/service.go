@@ -5,8 +5,14 @@ import (
 	"database/sql"
 	goErrors "errors"
 
+	"fmt"
+
+	"github.com/chall-goflutter-api/internal/media"
+	"github.com/chall-goflutter-api/internal/organisation"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/storage"
+	"github.com/chall-goflutter-api/pkg/utils"
 )
 
 type StandService interface {
@@ -16,34 +22,58 @@ type StandService interface {
 	Update(ctx context.Context, id int, input map[string]interface{}) error
 	GetCurrent(ctx context.Context) (types.Stand, error)
 	UpdateCurrent(ctx context.Context, input map[string]interface{}) error
+	UpdateStock(ctx context.Context, id int, input map[string]interface{}) error
+	GetStockHistory(ctx context.Context, id int) ([]types.StockMovement, error)
+	GetPromotions(ctx context.Context, id int) ([]types.Promotion, error)
+	CreatePromotion(ctx context.Context, id int, input map[string]interface{}) error
+	DeletePromotion(ctx context.Context, id int, promotionId int) error
+	UploadImage(ctx context.Context, id int, data []byte) error
+	UploadProductImage(ctx context.Context, id int, data []byte) error
+	Search(ctx context.Context, params map[string]interface{}) ([]types.StandSearchResult, error)
+	Delete(ctx context.Context, id int) error
 }
 
 type Service struct {
 	store StandStore
+	files storage.Storage
 }
 
-func NewService(store StandStore) *Service {
+func NewService(store StandStore, files storage.Storage) *Service {
 	return &Service{
 		store: store,
+		files: files,
 	}
 }
 
 func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]types.Stand, error) {
 	filtres := map[string]interface{}{}
 	if params["kermesse_id"] != nil {
-		filtres["kermesse_id"] = params["kermesse_id"]
+		kermesseId, err := utils.GetIntFromParams(params, "kermesse_id")
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		filtres["kermesse_id"] = kermesseId
 	}
 	if params["is_libre"] != nil {
 		filtres["is_libre"] = params["is_libre"]
 	}
+	if err := organisation.Scope(ctx, filtres); err != nil {
+		return nil, err
+	}
 
-	stands, err := s.store.FindAll(params)
+	stands, err := s.store.FindAll(filtres)
 	if err != nil {
 		return nil, errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
+	for i := range stands {
+		s.signUrls(&stands[i])
+	}
 
 	return stands, nil
 }
@@ -62,6 +92,7 @@ func (s *Service) Get(ctx context.Context, id int) (types.Stand, error) {
 			Err: err,
 		}
 	}
+	s.signUrls(&stand)
 
 	return stand, nil
 }
@@ -75,8 +106,33 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 	input["user_id"] = userId
+	organisationId, err := organisation.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	input["organisation_id"] = organisationId
+
+	standType, _ := input["type"].(string)
+	if err := prepareActivite(input, types.Stand{Type: standType}); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := prepareVente(input, types.Stand{Type: standType}); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := prepareCatalogue(input, types.Stand{Type: standType}); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
 
-	err := s.store.Create(input)
+	err = s.store.Create(input)
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
@@ -116,6 +172,26 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 		}
 	}
 
+	if err := prepareActivite(input, stand); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := prepareVente(input, stand); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := prepareCatalogue(input, stand); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	input["author_id"] = userId
+
 	err = s.store.Update(id, input)
 	if err != nil {
 		return errors.CustomError{
@@ -149,6 +225,7 @@ func (s *Service) GetCurrent(ctx context.Context) (types.Stand, error) {
 			Err: err,
 		}
 	}
+	s.signUrls(&stand)
 
 	return stand, nil
 }
@@ -162,13 +239,586 @@ func (s *Service) UpdateCurrent(ctx context.Context, input map[string]interface{
 		}
 	}
 
-	err := s.store.UpdateByUserId(userId, input)
+	stand, err := s.store.FindByUserId(userId)
 	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
 		return errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
 
+	if err := prepareActivite(input, stand); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := prepareVente(input, stand); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := prepareCatalogue(input, stand); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	err = s.store.UpdateByUserId(userId, input)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) UpdateStock(ctx context.Context, id int, input map[string]interface{}) error {
+	stand, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	if input["type"] != types.StockMovementRestock && input["type"] != types.StockMovementCorrection {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Type de mouvement invalide"),
+		}
+	}
+	quantity, err := utils.GetIntFromMap(input, "quantity")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if quantity == 0 || (input["type"] == types.StockMovementRestock && quantity < 0) {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Quantité invalide"),
+		}
+	}
+	if stand.Stock+quantity < 0 {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le stock ne peut pas être négatif"),
+		}
+	}
+
+	err = s.store.UpdateStock(id, quantity, map[string]interface{}{
+		"user_id": userId,
+		"type":    input["type"],
+		"reason":  input["reason"],
+	})
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetStockHistory(ctx context.Context, id int) ([]types.StockMovement, error) {
+	stand, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return nil, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		isOrganisateur, err := s.store.IsOrganisateur(id, userId)
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !isOrganisateur {
+			return nil, errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Interdit"),
+			}
+		}
+	}
+
+	movements, err := s.store.FindStockMovements(id)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return movements, nil
+}
+
+func (s *Service) GetPromotions(ctx context.Context, id int) ([]types.Promotion, error) {
+	promotions, err := s.store.FindPromotions(id)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return promotions, nil
+}
+
+func (s *Service) CreatePromotion(ctx context.Context, id int, input map[string]interface{}) error {
+	stand, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	if err := preparePromotion(input); err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if kermesseId, ok := input["kermesse_id"].(int); ok {
+		attached, err := s.store.IsAttached(id, kermesseId)
+		if err != nil {
+			return errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !attached {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: goErrors.New("Le stand ne participe pas à cette kermesse"),
+			}
+		}
+	}
+	input["stand_id"] = id
+
+	err = s.store.CreatePromotion(input)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) DeletePromotion(ctx context.Context, id int, promotionId int) error {
+	stand, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	found, err := s.store.DeletePromotion(id, promotionId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !found {
+		return errors.CustomError{
+			Key: errors.NotFound,
+			Err: goErrors.New("Promotion non trouvée"),
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) UploadImage(ctx context.Context, id int, data []byte) error {
+	stand, err := s.getOwned(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	key, thumbnailKey, err := media.SaveImage(s.files, fmt.Sprintf("stands/%d", id), data)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdateImage(id, key, thumbnailKey); err != nil {
+		media.Delete(s.files, &key, &thumbnailKey)
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	media.Delete(s.files, stand.ImageKey, stand.ThumbnailKey)
+
+	return nil
+}
+
+func (s *Service) UploadProductImage(ctx context.Context, id int, data []byte) error {
+	stand, err := s.getOwned(ctx, id)
+	if err != nil {
+		return err
+	}
+	if stand.Type != types.StandTypeVente {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Seuls les stands de vente ont une photo de produit"),
+		}
+	}
+
+	key, thumbnailKey, err := media.SaveImage(s.files, fmt.Sprintf("stands/%d/product", id), data)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdateProductImage(id, key, thumbnailKey); err != nil {
+		media.Delete(s.files, &key, &thumbnailKey)
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	media.Delete(s.files, stand.ProductImageKey, stand.ProductThumbnailKey)
+
+	return nil
+}
+
+// Supprime le stand de façon logique, une fois qu'il n'est plus engagé dans une kermesse active.
+func (s *Service) Delete(ctx context.Context, id int) error {
+	if _, err := s.getOwned(ctx, id); err != nil {
+		return err
+	}
+
+	inUse, err := s.store.IsInUse(id)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if inUse {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Le stand participe à une kermesse en cours"),
+		}
+	}
+
+	if err := s.store.Delete(id); err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Search(ctx context.Context, params map[string]interface{}) ([]types.StandSearchResult, error) {
+	filtres, err := prepareSearch(params)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	if err := organisation.Scope(ctx, filtres); err != nil {
+		return nil, err
+	}
+
+	results, err := s.store.Search(filtres)
+	if err != nil {
+		return nil, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	for i := range results {
+		s.signUrls(&results[i].Stand)
+	}
+
+	return results, nil
+}
+
+// Récupère un stand en vérifiant qu'il appartient à l'utilisateur connecté.
+func (s *Service) getOwned(ctx context.Context, id int) (types.Stand, error) {
+	stand, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return stand, errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return stand, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return stand, errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if stand.UserId != userId {
+		return stand, errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	return stand, nil
+}
+
+func (s *Service) signUrls(stand *types.Stand) {
+	stand.ImageUrl = media.URL(s.files, stand.ImageKey)
+	stand.ThumbnailUrl = media.URL(s.files, stand.ThumbnailKey)
+	stand.ProductImageUrl = media.URL(s.files, stand.ProductImageKey)
+	stand.ProductThumbnailUrl = media.URL(s.files, stand.ProductThumbnailKey)
+}
+
+// Valide les réglages propres aux stands d'activité (barème, délai d'abandon, capacité),
+// en conservant les valeurs actuelles de ceux qui ne sont pas fournis.
+func prepareActivite(input map[string]interface{}, current types.Stand) error {
+	isActivite := current.Type == types.StandTypeActivite
+
+	if value, ok := input["scoring"]; !ok {
+		input["scoring"] = current.Scoring
+	} else {
+		if value != nil && !isActivite {
+			return goErrors.New("Seuls les stands d'activité ont un barème")
+		}
+		scoring, err := ParseScoring(value)
+		if err != nil {
+			return err
+		}
+		input["scoring"] = scoring
+	}
+
+	if value, ok := input["timeout_minutes"]; !ok {
+		input["timeout_minutes"] = current.TimeoutMinutes
+	} else if value != nil {
+		if !isActivite {
+			return goErrors.New("Seuls les stands d'activité ont un délai d'abandon")
+		}
+		minutes, err := utils.GetIntFromMap(input, "timeout_minutes")
+		if err != nil {
+			return err
+		}
+		if minutes <= 0 {
+			return goErrors.New("Le délai d'abandon doit être positif")
+		}
+		input["timeout_minutes"] = minutes
+	}
+
+	if value, ok := input["capacity"]; !ok {
+		input["capacity"] = current.Capacity
+	} else if value != nil {
+		if !isActivite {
+			return goErrors.New("Seuls les stands d'activité ont une capacité")
+		}
+		capacity, err := utils.GetIntFromMap(input, "capacity")
+		if err != nil {
+			return err
+		}
+		if capacity <= 0 {
+			return goErrors.New("La capacité doit être positive")
+		}
+		input["capacity"] = capacity
+	}
+
+	if _, ok := input["timeout_points"]; !ok {
+		input["timeout_points"] = current.TimeoutPoints
+	} else {
+		points, err := utils.GetIntFromMap(input, "timeout_points")
+		if err != nil {
+			return err
+		}
+		if points < 0 {
+			return goErrors.New("Les points par défaut ne peuvent pas être négatifs")
+		}
+		input["timeout_points"] = points
+	}
+
+	return nil
+}
+
+// Valide le seuil d'alerte de stock des stands de vente, en conservant la valeur actuelle s'il n'est pas fourni.
+func prepareVente(input map[string]interface{}, current types.Stand) error {
+	value, ok := input["low_stock_threshold"]
+	if !ok {
+		input["low_stock_threshold"] = current.LowStockThreshold
+		return nil
+	}
+	if value == nil {
+		return nil
+	}
+	if current.Type != types.StandTypeVente {
+		return goErrors.New("Seuls les stands de vente ont un seuil d'alerte de stock")
+	}
+
+	threshold, err := utils.GetIntFromMap(input, "low_stock_threshold")
+	if err != nil {
+		return err
+	}
+	if threshold < 0 {
+		return goErrors.New("Le seuil d'alerte ne peut pas être négatif")
+	}
+	input["low_stock_threshold"] = threshold
+
+	return nil
+}
+
+// Valide une règle de promotion et normalise ses valeurs pour le store.
+func preparePromotion(input map[string]interface{}) error {
+	if name, ok := input["name"].(string); !ok || name == "" {
+		return goErrors.New("Le nom de la promotion est requis")
+	}
+
+	switch input["type"] {
+	case types.PromotionTypePercent, types.PromotionTypeFixed:
+		value, err := utils.GetIntFromMap(input, "value")
+		if err != nil {
+			return err
+		}
+		if value <= 0 || (input["type"] == types.PromotionTypePercent && value > 100) {
+			return goErrors.New("Valeur de remise invalide")
+		}
+		input["value"] = value
+		input["bundle_quantity"] = 0
+		input["bundle_price"] = 0
+	case types.PromotionTypeBundle:
+		quantity, err := utils.GetIntFromMap(input, "bundle_quantity")
+		if err != nil {
+			return err
+		}
+		price, err := utils.GetIntFromMap(input, "bundle_price")
+		if err != nil {
+			return err
+		}
+		if quantity < 2 || price < 0 {
+			return goErrors.New("Le lot doit contenir au moins 2 articles pour un prix positif")
+		}
+		input["value"] = 0
+		input["bundle_quantity"] = quantity
+		input["bundle_price"] = price
+	default:
+		return goErrors.New("Type de promotion invalide")
+	}
+
+	if input["kermesse_id"] != nil {
+		kermesseId, err := utils.GetIntFromMap(input, "kermesse_id")
+		if err != nil {
+			return err
+		}
+		input["kermesse_id"] = kermesseId
+	}
+
+	startsAt, err := utils.GetTimeFromMap(input, "starts_at")
+	if err != nil {
+		return err
+	}
+	endsAt, err := utils.GetTimeFromMap(input, "ends_at")
+	if err != nil {
+		return err
+	}
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return goErrors.New("La fin de la promotion doit être après son début")
+	}
+	input["starts_at"] = startsAt
+	input["ends_at"] = endsAt
+
 	return nil
 }
@@ -12,9 +12,21 @@ type StandStore interface {
 	FindById(id int) (types.Stand, error)
 	Create(input map[string]interface{}) error
 	Update(id int, input map[string]interface{}) error
-	UpdateStock(id int, n int) error
+	UpdateStock(id int, n int, input map[string]interface{}) error
 	FindByUserId(id int) (types.Stand, error)
 	UpdateByUserId(userId int, input map[string]interface{}) error
+	FindStockMovements(id int) ([]types.StockMovement, error)
+	IsOrganisateur(id int, userId int) (bool, error)
+	IsAttached(id int, kermesseId int) (bool, error)
+	FindPromotions(id int) ([]types.Promotion, error)
+	FindActivePromotions(id int, kermesseId interface{}) ([]types.Promotion, error)
+	CreatePromotion(input map[string]interface{}) error
+	DeletePromotion(id int, promotionId int) (bool, error)
+	UpdateImage(id int, key string, thumbnailKey string) error
+	UpdateProductImage(id int, key string, thumbnailKey string) error
+	Search(filtres map[string]interface{}) ([]types.StandSearchResult, error)
+	IsInUse(id int) (bool, error)
+	Delete(id int) error
 }
 
 type Store struct {
@@ -28,12 +40,23 @@ func NewStore(db *sqlx.DB) *Store {
 }
 
 const (
-	queryFindStandById  = "SELECT * FROM stands WHERE id=$1"
-	queryCreateStand    = "INSERT INTO stands (user_id, name, description, type, price, stock) VALUES ($1, $2, $3, $4, $5, $6)"
-	queryUpdateStand    = "UPDATE stands SET name=$1, description=$2, price=$3, stock=$4 WHERE id=$5"
-	queryUpdateStock    = "UPDATE stands SET stock=stock+$1 WHERE id=$2"
-	queryFindByUserId   = "SELECT * FROM stands WHERE user_id=$1 LIMIT 1"
-	queryUpdateByUserId = "UPDATE stands SET name=$1, description=$2, price=$3, stock=$4 WHERE user_id=$5"
+	queryFindStandById       = "SELECT s.*, (SELECT COUNT(*) FROM interactions i WHERE i.stand_id = s.id AND i.type = 'ACTIVITE' AND i.statut = 'STARTED') AS occupancy FROM stands s WHERE s.id=$1 AND s.deleted_at IS NULL"
+	queryCreateStand         = "INSERT INTO stands (user_id, name, description, type, price, stock, scoring, timeout_minutes, timeout_points, capacity, low_stock_threshold, category, tags, product_name, organisation_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)"
+	queryLockStandById       = "SELECT * FROM stands WHERE id=$1 FOR UPDATE"
+	queryUpdateStand         = "UPDATE stands SET name=$1, description=$2, price=$3, stock=$4, scoring=$5, timeout_minutes=$6, timeout_points=$7, capacity=$8, low_stock_threshold=$9, category=$10, tags=$11, product_name=$12 WHERE id=$13 RETURNING *"
+	queryUpdateStock         = "UPDATE stands SET stock=stock+$1 WHERE id=$2 RETURNING *"
+	queryFindByUserId        = "SELECT s.*, (SELECT COUNT(*) FROM interactions i WHERE i.stand_id = s.id AND i.type = 'ACTIVITE' AND i.statut = 'STARTED') AS occupancy FROM stands s WHERE s.user_id=$1 AND s.deleted_at IS NULL LIMIT 1"
+	queryLockStandByUserId   = "SELECT * FROM stands WHERE user_id=$1 AND deleted_at IS NULL LIMIT 1 FOR UPDATE"
+	queryUpdateByUserId      = "UPDATE stands SET name=$1, description=$2, price=$3, stock=$4, scoring=$5, timeout_minutes=$6, timeout_points=$7, capacity=$8, low_stock_threshold=$9, category=$10, tags=$11, product_name=$12 WHERE user_id=$13 AND deleted_at IS NULL RETURNING *"
+	queryCreateStockMovement = "INSERT INTO stock_movements (stand_id, user_id, type, quantity, stock_after, reason) VALUES ($1, $2, $3, $4, $5, COALESCE($6, ''))"
+	queryFindPromotions      = "SELECT * FROM promotions WHERE stand_id=$1 ORDER BY created_at DESC"
+	queryCreatePromotion     = "INSERT INTO promotions (stand_id, kermesse_id, name, type, value, bundle_quantity, bundle_price, starts_at, ends_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	queryDeletePromotion     = "DELETE FROM promotions WHERE id=$1 AND stand_id=$2"
+	queryUpdateImage         = "UPDATE stands SET image_key=$1, thumbnail_key=$2 WHERE id=$3"
+	queryUpdateProductImage  = "UPDATE stands SET product_image_key=$1, product_thumbnail_key=$2 WHERE id=$3"
+	queryDeleteStand         = "UPDATE stands SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL"
+	queryIsOrganisateur      = "SELECT EXISTS ( SELECT 1 FROM kermesses_stands ks JOIN kermesse_members km ON ks.kermesse_id = km.kermesse_id WHERE ks.stand_id = $1 AND km.user_id = $2 ) AS is_true"
+	queryIsAttached          = "SELECT EXISTS ( SELECT 1 FROM kermesses_stands WHERE stand_id=$1 AND kermesse_id=$2 ) AS is_true"
 )
 
 func (s *Store) FindAll(filtres map[string]interface{}) ([]types.Stand, error) {
@@ -42,17 +65,38 @@ func (s *Store) FindAll(filtres map[string]interface{}) ([]types.Stand, error) {
 		SELECT DISTINCT
 			s.id AS id,
 			s.user_id AS user_id,
+			s.organisation_id AS organisation_id,
 			s.name AS name,
 			s.description AS description,
 			s.type AS type,
 			s.price AS price,
-			s.stock AS stock
+			s.stock AS stock,
+			s.scoring AS scoring,
+			s.timeout_minutes AS timeout_minutes,
+			s.timeout_points AS timeout_points,
+			s.capacity AS capacity,
+			s.low_stock_threshold AS low_stock_threshold,
+			s.category AS category,
+			s.tags AS tags,
+			s.product_name AS product_name,
+			s.image_key AS image_key,
+			s.thumbnail_key AS thumbnail_key,
+			s.product_image_key AS product_image_key,
+			s.product_thumbnail_key AS product_thumbnail_key
 		FROM stands s
 		LEFT JOIN kermesses_stands ks ON s.id = ks.stand_id
-		WHERE 1=1 AND s.id IS NOT NULL
+		WHERE 1=1 AND s.id IS NOT NULL AND s.deleted_at IS NULL
 	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filtres["organisation_id"] != nil {
+		query += fmt.Sprintf(" AND s.organisation_id = %s", arg(filtres["organisation_id"]))
+	}
 	if filtres["kermesse_id"] != nil {
-		query += fmt.Sprintf(" AND ks.kermesse_id IS NOT NULL AND ks.kermesse_id = %v", filtres["kermesse_id"])
+		query += fmt.Sprintf(" AND ks.kermesse_id IS NOT NULL AND ks.kermesse_id = %s", arg(filtres["kermesse_id"]))
 	}
 	if filtres["is_libre"] != nil {
 		query += `
@@ -62,12 +106,12 @@ func (s *Store) FindAll(filtres map[string]interface{}) ([]types.Stand, error) {
 					SELECT ks_inner.stand_id 
 					FROM kermesses_stands ks_inner
 					JOIN kermesses k ON ks_inner.kermesse_id = k.id
-					WHERE k.statut = 'STARTED'
+					WHERE k.statut NOT IN ('CLOSED', 'ARCHIVED') AND NOT k.is_template AND k.deleted_at IS NULL
 				)
 			)
     `
 	}
-	err := s.db.Select(&stands, query)
+	err := s.db.Select(&stands, query, args...)
 
 	return stands, err
 }
@@ -80,23 +124,79 @@ func (s *Store) FindById(id int) (types.Stand, error) {
 }
 
 func (s *Store) Create(input map[string]interface{}) error {
-	_, err := s.db.Exec(queryCreateStand, input["user_id"], input["name"], input["description"], input["type"], input["price"], input["stock"])
+	_, err := s.db.Exec(queryCreateStand, input["user_id"], input["name"], input["description"], input["type"], input["price"], input["stock"], input["scoring"], input["timeout_minutes"], input["timeout_points"], input["capacity"], input["low_stock_threshold"], input["category"], input["tags"], input["product_name"], input["organisation_id"])
 
 	return err
 }
 
-func (s *Store) Update(id int, input map[string]interface{}) error {
-	_, err := s.db.Exec(queryUpdateStand, input["name"], input["description"], input["price"], input["stock"], id)
+func (s *Store) Update(id int, input map[string]interface{}) (err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	before := types.Stand{}
+	if err = tx.Get(&before, queryLockStandById, id); err != nil {
+		return err
+	}
+	after := types.Stand{}
+	err = tx.Get(&after, queryUpdateStand, input["name"], input["description"], input["price"], input["stock"], input["scoring"], input["timeout_minutes"], input["timeout_points"], input["capacity"], input["low_stock_threshold"], input["category"], input["tags"], input["product_name"], id)
+	if err != nil {
+		return err
+	}
 
+	err = recordMovement(tx, before, after, map[string]interface{}{
+		"user_id": input["author_id"],
+		"type":    types.StockMovementCorrection,
+		"reason":  input["stock_reason"],
+	})
 	return err
 }
 
-func (s *Store) UpdateStock(id int, quantity int) error {
-	_, err := s.db.Exec(queryUpdateStock, quantity, id)
+func (s *Store) UpdateStock(id int, quantity int, input map[string]interface{}) (err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
 
+	err = MoveStock(tx, id, quantity, input)
 	return err
 }
 
+// Variation de stock dans une transaction existante, journalisée comme UpdateStock.
+func MoveStock(tx *sqlx.Tx, id int, quantity int, input map[string]interface{}) error {
+	after := types.Stand{}
+	if err := tx.Get(&after, queryUpdateStock, quantity, id); err != nil {
+		return err
+	}
+	before := after
+	before.Stock -= quantity
+
+	return recordMovement(tx, before, after, input)
+}
+
 func (s *Store) FindByUserId(userId int) (types.Stand, error) {
 	stand := types.Stand{}
 	err := s.db.Get(&stand, queryFindByUserId, userId)
@@ -104,8 +204,248 @@ func (s *Store) FindByUserId(userId int) (types.Stand, error) {
 	return stand, err
 }
 
-func (s *Store) UpdateByUserId(userId int, input map[string]interface{}) error {
-	_, err := s.db.Exec(queryUpdateByUserId, input["name"], input["description"], input["price"], input["stock"], userId)
+func (s *Store) UpdateByUserId(userId int, input map[string]interface{}) (err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	before := types.Stand{}
+	if err = tx.Get(&before, queryLockStandByUserId, userId); err != nil {
+		return err
+	}
+	after := types.Stand{}
+	err = tx.Get(&after, queryUpdateByUserId, input["name"], input["description"], input["price"], input["stock"], input["scoring"], input["timeout_minutes"], input["timeout_points"], input["capacity"], input["low_stock_threshold"], input["category"], input["tags"], input["product_name"], userId)
+	if err != nil {
+		return err
+	}
+
+	err = recordMovement(tx, before, after, map[string]interface{}{
+		"user_id": userId,
+		"type":    types.StockMovementCorrection,
+		"reason":  input["stock_reason"],
+	})
+	return err
+}
+
+func (s *Store) FindStockMovements(id int) ([]types.StockMovement, error) {
+	movements := []types.StockMovement{}
+	query := `
+		SELECT
+			sm.id AS id,
+			sm.stand_id AS stand_id,
+			sm.type AS type,
+			sm.quantity AS quantity,
+			sm.stock_after AS stock_after,
+			sm.reason AS reason,
+			sm.created_at AS created_at,
+			u.id AS "user.id",
+			u.name AS "user.name",
+			u.role AS "user.role"
+		FROM stock_movements sm
+		JOIN users u ON sm.user_id = u.id
+		WHERE sm.stand_id = $1
+		ORDER BY sm.created_at DESC, sm.id DESC
+	`
+	err := s.db.Select(&movements, query, id)
+
+	return movements, err
+}
+
+func (s *Store) IsOrganisateur(id int, userId int) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryIsOrganisateur, id, userId).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+func (s *Store) IsAttached(id int, kermesseId int) (bool, error) {
+	var isTrue bool
+	err := s.db.QueryRow(queryIsAttached, id, kermesseId).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+func (s *Store) FindPromotions(id int) ([]types.Promotion, error) {
+	promotions := []types.Promotion{}
+	err := s.db.Select(&promotions, queryFindPromotions, id)
+
+	return promotions, err
+}
+
+// Promotions du stand en cours de validité, globales ou limitées à la kermesse donnée.
+// Aucune promotion ne s'applique si le stand ne participe pas à cette kermesse.
+func (s *Store) FindActivePromotions(id int, kermesseId interface{}) ([]types.Promotion, error) {
+	promotions := []types.Promotion{}
+	query := `
+		SELECT p.*
+		FROM promotions p
+		JOIN kermesses_stands ks ON ks.stand_id = p.stand_id AND ks.kermesse_id = $2
+		WHERE p.stand_id = $1
+		AND (p.kermesse_id IS NULL OR p.kermesse_id = $2)
+		AND (p.starts_at IS NULL OR p.starts_at <= NOW())
+		AND (p.ends_at IS NULL OR p.ends_at > NOW())
+	`
+	err := s.db.Select(&promotions, query, id, kermesseId)
+
+	return promotions, err
+}
+
+func (s *Store) CreatePromotion(input map[string]interface{}) error {
+	_, err := s.db.Exec(queryCreatePromotion, input["stand_id"], input["kermesse_id"], input["name"], input["type"], input["value"], input["bundle_quantity"], input["bundle_price"], input["starts_at"], input["ends_at"])
+
+	return err
+}
+
+func (s *Store) DeletePromotion(id int, promotionId int) (bool, error) {
+	result, err := s.db.Exec(queryDeletePromotion, promotionId, id)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+
+	return count > 0, err
+}
+
+// Journalise la variation de stock entre before et after, et alerte le teneur de stand
+// et les organisateurs de ses kermesses en cours quand le stock passe sous le seuil d'alerte.
+func recordMovement(tx *sqlx.Tx, before types.Stand, after types.Stand, input map[string]interface{}) error {
+	quantity := after.Stock - before.Stock
+	if quantity == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(queryCreateStockMovement, after.Id, input["user_id"], input["type"], quantity, after.Stock, input["reason"])
+	if err != nil {
+		return err
+	}
+
+	threshold := after.LowStockThreshold
+	if threshold == nil || after.Stock > *threshold || before.Stock <= *threshold {
+		return nil
+	}
+	query := `
+		INSERT INTO notifications (user_id, type, message)
+		SELECT DISTINCT r.user_id, $2, $3
+		FROM (
+			SELECT s.user_id FROM stands s WHERE s.id = $1
+			UNION
+			SELECT km.user_id
+			FROM kermesses k
+			JOIN kermesses_stands ks ON ks.kermesse_id = k.id
+			JOIN kermesse_members km ON km.kermesse_id = k.id
+			WHERE ks.stand_id = $1 AND k.statut = $4 AND km.role IN ($5, $6)
+		) r
+	`
+	message := fmt.Sprintf("Stock bas pour le stand %s : %d restant(s)", after.Name, after.Stock)
+	_, err = tx.Exec(query, after.Id, types.NotificationTypeLowStock, message, types.KermesseStatutOpen, types.KermesseMemberOwner, types.KermesseMemberCoOrganisateur)
+
+	return err
+}
+
+func (s *Store) UpdateImage(id int, key string, thumbnailKey string) error {
+	_, err := s.db.Exec(queryUpdateImage, key, thumbnailKey, id)
+
+	return err
+}
+
+func (s *Store) UpdateProductImage(id int, key string, thumbnailKey string) error {
+	_, err := s.db.Exec(queryUpdateProductImage, key, thumbnailKey, id)
+
+	return err
+}
+
+// Recherche plein texte sur le nom, le produit, la catégorie, les tags et la description,
+// les résultats les plus pertinents en premier.
+func (s *Store) Search(filtres map[string]interface{}) ([]types.StandSearchResult, error) {
+	results := []types.StandSearchResult{}
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	occupancy := "(SELECT COUNT(*) FROM interactions i WHERE i.stand_id = s.id AND i.type = 'ACTIVITE' AND i.statut = 'STARTED')"
+	rank := "0"
+	where := ""
+	if filtres["q"] != nil {
+		tsquery := fmt.Sprintf("websearch_to_tsquery('french', %s)", arg(filtres["q"]))
+		vector := "stand_search_vector(s.name, s.description, s.product_name, s.category, s.tags)"
+		rank = fmt.Sprintf("ts_rank(%s, %s)", vector, tsquery)
+		where += fmt.Sprintf(" AND %s @@ %s", vector, tsquery)
+	}
+	if filtres["organisation_id"] != nil {
+		where += fmt.Sprintf(" AND s.organisation_id = %s", arg(filtres["organisation_id"]))
+	}
+	if filtres["type"] != nil {
+		where += fmt.Sprintf(" AND s.type = %s", arg(filtres["type"]))
+	}
+	if filtres["category"] != nil {
+		where += fmt.Sprintf(" AND s.category = %s", arg(filtres["category"]))
+	}
+	if filtres["tags"] != nil {
+		where += fmt.Sprintf(" AND s.tags @> %s", arg(filtres["tags"]))
+	}
+	if filtres["min_price"] != nil {
+		where += fmt.Sprintf(" AND s.price >= %s", arg(filtres["min_price"]))
+	}
+	if filtres["max_price"] != nil {
+		where += fmt.Sprintf(" AND s.price <= %s", arg(filtres["max_price"]))
+	}
+	if filtres["kermesse_id"] != nil {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM kermesses_stands ks WHERE ks.stand_id = s.id AND ks.kermesse_id = %s)", arg(filtres["kermesse_id"]))
+	}
+	if filtres["available"] != nil {
+		// Disponible : du stock pour une vente, une place libre pour une activité
+		where += fmt.Sprintf(`
+			AND (
+				(s.type = '%s' AND s.stock > 0)
+				OR (s.type = '%s' AND (s.capacity IS NULL OR %s < s.capacity))
+			)
+		`, types.StandTypeVente, types.StandTypeActivite, occupancy)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT s.*, %s AS occupancy, %s AS rank
+		FROM stands s
+		WHERE s.deleted_at IS NULL %s
+		ORDER BY rank DESC, s.name
+		LIMIT %s
+	`, occupancy, rank, where, arg(filtres["limit"]))
+	err := s.db.Select(&results, query, args...)
+
+	return results, err
+}
+
+// Indique si le stand est rattaché à une kermesse encore active.
+func (s *Store) IsInUse(id int) (bool, error) {
+	var isTrue bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM kermesses_stands ks
+			JOIN kermesses k ON ks.kermesse_id = k.id
+			WHERE ks.stand_id = $1 AND k.statut NOT IN ($2, $3) AND NOT k.is_template AND k.deleted_at IS NULL
+		) AS is_true
+	`
+	err := s.db.QueryRow(query, id, types.KermesseStatutClosed, types.KermesseStatutArchived).Scan(&isTrue)
+
+	return isTrue, err
+}
+
+func (s *Store) Delete(id int) error {
+	_, err := s.db.Exec(queryDeleteStand, id)
 
 	return err
 }
@@ -5,9 +5,9 @@ import (
 	"database/sql"
 	goErrors "errors"
 
+	"github.com/chall-goflutter-api/internal/organisation"
 	"github.com/chall-goflutter-api/internal/tombola"
 	"github.com/chall-goflutter-api/internal/types"
-	"github.com/chall-goflutter-api/internal/user"
 	"github.com/chall-goflutter-api/pkg/errors"
 	"github.com/chall-goflutter-api/pkg/utils"
 )
@@ -21,14 +21,12 @@ type TicketService interface {
 type Service struct {
 	store        TicketStore
 	tombolaStore tombola.TombolaStore
-	userStore    user.UserStore
 }
 
-func NewService(store TicketStore, tombolaStore tombola.TombolaStore, userStore user.UserStore) *Service {
+func NewService(store TicketStore, tombolaStore tombola.TombolaStore) *Service {
 	return &Service{
 		store:        store,
 		tombolaStore: tombolaStore,
-		userStore:    userStore,
 	}
 }
 
@@ -56,6 +54,9 @@ func (s *Service) GetAll(ctx context.Context) ([]types.Ticket, error) {
 	} else if userRole == types.UserRoleEnfant {
 		filters["enfant_id"] = userId
 	}
+	if err := organisation.Scope(ctx, filters); err != nil {
+		return nil, err
+	}
 
 	tickets, err := s.store.FindAll(filters)
 	if err != nil {
@@ -108,10 +109,16 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
-	if tombola.Statut == types.TombolaStatutEnded {
+	if tombola.Statut != types.TombolaStatutStarted {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: ErrTombolaClosed,
+		}
+	}
+	if tombola.SeedHash == nil {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("La tombola est déjà terminée"),
+			Err: ErrNotCommitted,
 		}
 	}
 
@@ -122,27 +129,6 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
 		}
 	}
-	user, err := s.userStore.FindById(userId)
-	if err != nil {
-		if goErrors.Is(err, sql.ErrNoRows) {
-			return errors.CustomError{
-				Key: errors.NotFound,
-				Err: err,
-			}
-		}
-		return errors.CustomError{
-			Key: errors.InternalServerError,
-			Err: err,
-		}
-	}
-
-	// Check si l'utilisateur a assez de jetons
-	if user.Jetons < tombola.Price {
-		return errors.CustomError{
-			Key: errors.BadRequest,
-			Err: goErrors.New("Pas assez de jetons"),
-		}
-	}
 
 	// Check si l'utilisateur peut participer à la tombola
 	canCreate, err := s.store.CanCreate(map[string]interface{}{
@@ -162,19 +148,18 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
-	// Mettre à jour les jetons de l'utilisateur
-	err = s.userStore.UpdateJetons(userId, -tombola.Price)
+	// Le statut, le prix et le solde sont revérifiés dans la transaction d'achat
+	_, err = s.store.Create(map[string]interface{}{
+		"user_id":    userId,
+		"tombola_id": tombolaId,
+	})
 	if err != nil {
-		return errors.CustomError{
-			Key: errors.InternalServerError,
-			Err: err,
+		if goErrors.Is(err, ErrTombolaClosed) || goErrors.Is(err, ErrNotCommitted) || goErrors.Is(err, ErrNotEnoughJetons) {
+			return errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
 		}
-	}
-
-	input["user_id"] = userId
-
-	err = s.store.Create(input)
-	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
@@ -0,0 +1,120 @@
+package ticket
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"testing"
+
+	"github.com/chall-goflutter-api/internal/tombola"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+type fakeTicketStore struct {
+	TicketStore
+	err     error
+	created []map[string]interface{}
+}
+
+func (f *fakeTicketStore) CanCreate(input map[string]interface{}) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeTicketStore) Create(input map[string]interface{}) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.created = append(f.created, input)
+	return len(f.created), nil
+}
+
+type fakeTombolaStore struct {
+	tombola.TombolaStore
+	tombola types.Tombola
+}
+
+func (f *fakeTombolaStore) FindById(id int) (types.Tombola, error) {
+	if id != f.tombola.Id {
+		return types.Tombola{}, sql.ErrNoRows
+	}
+	return f.tombola, nil
+}
+
+func newTestService(tombola types.Tombola, err error) (*Service, *fakeTicketStore) {
+	tickets := &fakeTicketStore{err: err}
+	service := NewService(tickets, &fakeTombolaStore{tombola: tombola})
+
+	return service, tickets
+}
+
+func buy(service *Service, tombolaId int) error {
+	ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+	return service.Create(ctx, map[string]interface{}{"tombola_id": float64(tombolaId)})
+}
+
+func errorKey(err error) string {
+	var customErr errors.CustomError
+	if goErrors.As(err, &customErr) {
+		return customErr.Key
+	}
+	return ""
+}
+
+// Empreinte publiée par Commit, sans laquelle la vente n'est pas ouverte
+var seedHash = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+
+func TestCreate(t *testing.T) {
+	service, tickets := newTestService(types.Tombola{Id: 3, KermesseId: 7, Statut: types.TombolaStatutStarted, Price: 4, SeedHash: &seedHash}, nil)
+
+	if err := buy(service, 3); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if len(tickets.created) != 1 {
+		t.Fatalf("%d tickets créés, attendu 1", len(tickets.created))
+	}
+	// Le prix est relu par le store dans la transaction, pas transmis par le service
+	created := tickets.created[0]
+	if created["user_id"] != 1 || created["tombola_id"] != 3 || created["price"] != nil {
+		t.Errorf("ticket créé = %v", created)
+	}
+}
+
+func TestCreateRefused(t *testing.T) {
+	tests := []struct {
+		name     string
+		statut   string
+		seedHash *string
+		err      error
+		wantKey  string
+	}{
+		{"tombola terminée", types.TombolaStatutEnded, &seedHash, nil, errors.BadRequest},
+		{"tombola annulée", types.TombolaStatutCancelled, &seedHash, nil, errors.BadRequest},
+		{"sans engagement", types.TombolaStatutStarted, nil, nil, errors.BadRequest},
+		{"tombola terminée pendant l'achat", types.TombolaStatutStarted, &seedHash, ErrTombolaClosed, errors.BadRequest},
+		{"pas assez de jetons", types.TombolaStatutStarted, &seedHash, ErrNotEnoughJetons, errors.BadRequest},
+		{"erreur du store", types.TombolaStatutStarted, &seedHash, goErrors.New("connexion perdue"), errors.InternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, tickets := newTestService(types.Tombola{Id: 3, KermesseId: 7, Statut: tt.statut, Price: 4, SeedHash: tt.seedHash}, tt.err)
+
+			err := buy(service, 3)
+			if errorKey(err) != tt.wantKey {
+				t.Fatalf("erreur = %v, attendu %s", err, tt.wantKey)
+			}
+			if len(tickets.created) != 0 {
+				t.Errorf("aucun ticket attendu, tickets = %v", tickets.created)
+			}
+		})
+	}
+}
+
+func TestCreateUnknownTombola(t *testing.T) {
+	service, _ := newTestService(types.Tombola{Id: 3, Statut: types.TombolaStatutStarted}, nil)
+
+	if err := buy(service, 4); errorKey(err) != errors.NotFound {
+		t.Fatalf("erreur = %v, attendu %s", err, errors.NotFound)
+	}
+}
@@ -1,16 +1,25 @@
 package ticket
 
 import (
+	"database/sql"
+	goErrors "errors"
 	"fmt"
 
+	"github.com/chall-goflutter-api/internal/kermesse"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/jmoiron/sqlx"
 )
 
+var (
+	ErrTombolaClosed   = goErrors.New("La tombola est déjà terminée")
+	ErrNotCommitted    = goErrors.New("La vente ouvre une fois l'engagement du tirage publié")
+	ErrNotEnoughJetons = goErrors.New("Pas assez de jetons")
+)
+
 type TicketStore interface {
 	FindAll(filters map[string]interface{}) ([]types.Ticket, error)
 	FindById(id int) (types.Ticket, error)
-	Create(input map[string]interface{}) error
+	Create(input map[string]interface{}) (int, error)
 	CanCreate(input map[string]interface{}) (bool, error)
 }
 
@@ -25,7 +34,9 @@ func NewStore(db *sqlx.DB) *Store {
 }
 
 const (
-	queryCreateTicket = "INSERT INTO tickets (user_id, tombola_id) VALUES ($1, $2)"
+	queryCreateTicket = "INSERT INTO tickets (user_id, tombola_id, price) VALUES ($1, $2, $3) RETURNING id"
+	queryLockTombola  = "SELECT kermesse_id, price, seed_hash FROM tombolas WHERE id=$1 AND statut=$2 AND deleted_at IS NULL FOR UPDATE"
+	queryDebitJetons  = "UPDATE users SET jetons=jetons-$1 WHERE id=$2 AND jetons >= $1"
 )
 
 func (s *Store) FindAll(filters map[string]interface{}) ([]types.Ticket, error) {
@@ -51,18 +62,26 @@ func (s *Store) FindAll(filters map[string]interface{}) ([]types.Ticket, error)
 		JOIN users u ON t.user_id = u.id
 		JOIN tombolas tb ON t.tombola_id = tb.id
 		JOIN kermesses k ON tb.kermesse_id = k.id
-		WHERE 1=1
+		WHERE k.statut <> 'ARCHIVED' AND k.deleted_at IS NULL
 	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filters["organisation_id"] != nil {
+		query += fmt.Sprintf(" AND k.organisation_id = %s", arg(filters["organisation_id"]))
+	}
 	if filters["organisateur_id"] != nil {
-		query += fmt.Sprintf(" AND k.user_id IS NOT NULL AND k.user_id = %v", filters["organisateur_id"])
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM kermesse_members km WHERE km.kermesse_id = k.id AND km.user_id = %s)", arg(filters["organisateur_id"]))
 	}
 	if filters["parent_id"] != nil {
-		query += fmt.Sprintf(" AND u.parent_id IS NOT NULL AND u.parent_id = %v", filters["parent_id"])
+		query += fmt.Sprintf(" AND u.parent_id IS NOT NULL AND u.parent_id = %s", arg(filters["parent_id"]))
 	}
 	if filters["enfant_id"] != nil {
-		query += fmt.Sprintf(" AND t.user_id IS NOT NULL AND t.user_id = %v", filters["enfant_id"])
+		query += fmt.Sprintf(" AND t.user_id IS NOT NULL AND t.user_id = %s", arg(filters["enfant_id"]))
 	}
-	err := s.db.Select(&tickets, query)
+	err := s.db.Select(&tickets, query, args...)
 
 	return tickets, err
 }
@@ -107,13 +126,81 @@ func (s *Store) CanCreate(input map[string]interface{}) (bool, error) {
 			WHERE ku.kermesse_id = $1 AND ku.user_id = $2 AND k.statut = $3
 		) AS is_associated
 	`
-	err := s.db.QueryRow(query, input["kermesse_id"], input["user_id"], types.KermesseStatutStarted).Scan(&isAssociated)
+	err := s.db.QueryRow(query, input["kermesse_id"], input["user_id"], types.KermesseStatutOpen).Scan(&isAssociated)
 
 	return isAssociated, err
 }
 
-func (s *Store) Create(input map[string]interface{}) error {
-	_, err := s.db.Exec(queryCreateTicket, input["user_id"], input["tombola_id"])
+// Achète un ticket au prix de la tombola et le crédite à la trésorerie de la kermesse.
+// La tombola reste verrouillée jusqu'à la fin de l'achat : elle ne peut pas être
+// tirée ou annulée entre la vérification et la vente.
+func (s *Store) Create(input map[string]interface{}) (id int, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
 
-	return err
+	var kermesseId, price int
+	var seedHash *string
+	err = tx.QueryRow(queryLockTombola, input["tombola_id"], types.TombolaStatutStarted).Scan(&kermesseId, &price, &seedHash)
+	if goErrors.Is(err, sql.ErrNoRows) {
+		return 0, ErrTombolaClosed
+	}
+	if err != nil {
+		return 0, err
+	}
+	// Un ticket vendu avant l'engagement laisserait choisir la graine en connaissant les tickets
+	if seedHash == nil {
+		return 0, ErrNotCommitted
+	}
+
+	result, err := tx.Exec(queryDebitJetons, price, input["user_id"])
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, ErrNotEnoughJetons
+	}
+
+	if err = tx.QueryRow(queryCreateTicket, input["user_id"], input["tombola_id"], price).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if entry := saleEntry(kermesseId, price, id); entry != nil {
+		if err = kermesse.RecordTreasury(tx, entry); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+// Mouvement de trésorerie de la vente : le prix du ticket revient à la kermesse.
+// nil pour un ticket gratuit.
+func saleEntry(kermesseId int, price int, ticketId int) map[string]interface{} {
+	if price <= 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"kermesse_id": kermesseId,
+		"type":        types.TreasuryTransactionTicket,
+		"amount":      price,
+		"ticket_id":   ticketId,
+	}
 }
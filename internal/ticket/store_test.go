@@ -0,0 +1,23 @@
+package ticket
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+func TestSaleEntry(t *testing.T) {
+	want := map[string]interface{}{
+		"kermesse_id": 7,
+		"type":        types.TreasuryTransactionTicket,
+		"amount":      4,
+		"ticket_id":   12,
+	}
+	if entry := saleEntry(7, 4, 12); !reflect.DeepEqual(entry, want) {
+		t.Errorf("mouvement = %v, attendu %v", entry, want)
+	}
+	if entry := saleEntry(7, 0, 12); entry != nil {
+		t.Errorf("mouvement = %v pour un ticket gratuit, attendu aucun", entry)
+	}
+}
@@ -0,0 +1,48 @@
+package tombola
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxSeedLength = 128
+
+const DrawAlgorithm = `sha256(seed + ":" + ticket_ids triés par ordre croissant et séparés par ","), lu comme un entier big-endian, modulo le nombre de tickets : rang du ticket gagnant dans ticket_ids`
+
+// Empreinte publiée pour s'engager sur la graine avant la fin des ventes.
+func HashSeed(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Tirage déterministe décrit par DrawAlgorithm. Renvoie le ticket gagnant (0 sans ticket)
+// et l'empreinte hexadécimale dont il est tiré.
+func Draw(seed string, ticketIds []int) (int, string) {
+	ids := append([]int{}, ticketIds...)
+	sort.Ints(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	sum := sha256.Sum256([]byte(seed + ":" + strings.Join(parts, ",")))
+	digest := hex.EncodeToString(sum[:])
+	if len(ids) == 0 {
+		return 0, digest
+	}
+
+	index := new(big.Int).Mod(new(big.Int).SetBytes(sum[:]), big.NewInt(int64(len(ids))))
+	return ids[index.Int64()], digest
+}
+
+func isSeedHash(value string) bool {
+	if len(value) != sha256.Size*2 || strings.ToLower(value) != value {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
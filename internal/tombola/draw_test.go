@@ -0,0 +1,41 @@
+package tombola
+
+import "testing"
+
+func TestHashSeed(t *testing.T) {
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := HashSeed("abc"); got != want {
+		t.Errorf("HashSeed(abc) = %s, attendu %s", got, want)
+	}
+	if !isSeedHash(want) {
+		t.Errorf("isSeedHash(%s) = false", want)
+	}
+	for _, value := range []string{"", "abc", want[:63], "BA7816BF8F01CFEA414140DE5DAE2223B00361A396177A9CB410FF61F20015AD", want[:63] + "z"} {
+		if isSeedHash(value) {
+			t.Errorf("isSeedHash(%q) = true", value)
+		}
+	}
+}
+
+// Valeur recalculable à la main : sha256("kermesse-2026:4,9,15,22") modulo 4 vaut 1.
+func TestDraw(t *testing.T) {
+	winner, digest := Draw("kermesse-2026", []int{22, 4, 15, 9})
+	if digest != "9bdbe6bc4cf1593d1ee2cf15a7d070be8f8477b5dfeed6ccd608e8f5e3a975d5" {
+		t.Errorf("digest = %s", digest)
+	}
+	if winner != 9 {
+		t.Errorf("gagnant = %d, attendu 9", winner)
+	}
+
+	// L'ordre de lecture des tickets ne change pas le résultat
+	again, _ := Draw("kermesse-2026", []int{4, 9, 15, 22})
+	if again != winner {
+		t.Errorf("gagnant = %d après tri, attendu %d", again, winner)
+	}
+}
+
+func TestDrawWithoutTickets(t *testing.T) {
+	if winner, _ := Draw("kermesse-2026", []int{}); winner != 0 {
+		t.Errorf("gagnant = %d sans ticket, attendu 0", winner)
+	}
+}
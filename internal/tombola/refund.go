@@ -0,0 +1,59 @@
+package tombola
+
+import (
+	"sort"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+// Ticket vendu, tel que relu pour l'annulation de la tombola.
+type soldTicket struct {
+	Id     int `db:"id"`
+	UserId int `db:"user_id"`
+	Price  int `db:"price"`
+}
+
+type refund struct {
+	UserId int
+	Amount int
+}
+
+// Regroupe les tickets par acheteur, chacun étant remboursé à son prix d'achat.
+// Les acheteurs sont triés pour verrouiller leurs lignes toujours dans le même ordre.
+func refunds(tickets []soldTicket) []refund {
+	amounts := map[int]int{}
+	for _, ticket := range tickets {
+		if ticket.Price <= 0 {
+			continue
+		}
+		amounts[ticket.UserId] += ticket.Price
+	}
+
+	byUser := make([]refund, 0, len(amounts))
+	for userId, amount := range amounts {
+		byUser = append(byUser, refund{UserId: userId, Amount: amount})
+	}
+	sort.Slice(byUser, func(i, j int) bool {
+		return byUser[i].UserId < byUser[j].UserId
+	})
+
+	return byUser
+}
+
+// Mouvements de trésorerie de l'annulation, un par ticket payant, au prix d'achat.
+func refundEntries(kermesseId int, tickets []soldTicket) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	for _, ticket := range tickets {
+		if ticket.Price <= 0 {
+			continue
+		}
+		entries = append(entries, map[string]interface{}{
+			"kermesse_id": kermesseId,
+			"type":        types.TreasuryTransactionTicketRefund,
+			"amount":      -ticket.Price,
+			"ticket_id":   ticket.Id,
+		})
+	}
+
+	return entries
+}
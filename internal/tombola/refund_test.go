@@ -0,0 +1,59 @@
+package tombola
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chall-goflutter-api/internal/types"
+)
+
+func TestRefunds(t *testing.T) {
+	// Le prix a changé entre deux ventes : chaque ticket est remboursé à son prix d'achat
+	tickets := []soldTicket{
+		{Id: 1, UserId: 8, Price: 4},
+		{Id: 2, UserId: 5, Price: 4},
+		{Id: 3, UserId: 8, Price: 6},
+		{Id: 4, UserId: 9, Price: 0},
+	}
+
+	byUser := refunds(tickets)
+	want := []refund{{UserId: 5, Amount: 4}, {UserId: 8, Amount: 10}}
+	if !reflect.DeepEqual(byUser, want) {
+		t.Errorf("remboursements = %v, attendu %v", byUser, want)
+	}
+}
+
+func TestRefundsWithoutTickets(t *testing.T) {
+	if byUser := refunds(nil); len(byUser) != 0 {
+		t.Errorf("remboursements = %v, attendu aucun", byUser)
+	}
+}
+
+func TestRefundEntries(t *testing.T) {
+	tickets := []soldTicket{
+		{Id: 1, UserId: 8, Price: 4},
+		{Id: 2, UserId: 5, Price: 0},
+		{Id: 3, UserId: 8, Price: 6},
+	}
+
+	entries := refundEntries(7, tickets)
+	want := []map[string]interface{}{
+		{"kermesse_id": 7, "type": types.TreasuryTransactionTicketRefund, "amount": -4, "ticket_id": 1},
+		{"kermesse_id": 7, "type": types.TreasuryTransactionTicketRefund, "amount": -6, "ticket_id": 3},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("mouvements = %v, attendu %v", entries, want)
+	}
+
+	// La trésorerie est débitée d'autant que les acheteurs sont remboursés
+	debited, refunded := 0, 0
+	for _, entry := range entries {
+		debited -= entry["amount"].(int)
+	}
+	for _, r := range refunds(tickets) {
+		refunded += r.Amount
+	}
+	if debited != refunded {
+		t.Errorf("trésorerie débitée de %d, acheteurs remboursés de %d", debited, refunded)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	goErrors "errors"
 
 	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/organisation"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/chall-goflutter-api/pkg/errors"
 	"github.com/chall-goflutter-api/pkg/utils"
@@ -16,7 +17,11 @@ type TombolaService interface {
 	Get(ctx context.Context, id int) (types.Tombola, error)
 	Create(ctx context.Context, input map[string]interface{}) error
 	Update(ctx context.Context, id int, input map[string]interface{}) error
-	End(ctx context.Context, id int) error
+	End(ctx context.Context, id int, input map[string]interface{}) error
+	Commit(ctx context.Context, id int, input map[string]interface{}) error
+	GetAudit(ctx context.Context, id int) (types.TombolaAudit, error)
+	Cancel(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int) error
 }
 
 type Service struct {
@@ -34,7 +39,17 @@ func NewService(store TombolaStore, kermesseStore kermesse.KermesseStore) *Servi
 func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]types.Tombola, error) {
 	filters := map[string]interface{}{}
 	if params["kermesse_id"] != nil {
-		filters["kermesse_id"] = params["kermesse_id"]
+		kermesseId, err := utils.GetIntFromParams(params, "kermesse_id")
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		filters["kermesse_id"] = kermesseId
+	}
+	if err := organisation.Scope(ctx, filters); err != nil {
+		return nil, err
 	}
 
 	tombolas, err := s.store.FindAll(filters)
@@ -73,7 +88,31 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 			Err: error,
 		}
 	}
-	kermesse, err := s.kermesseStore.FindById(kermesseId)
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, kermesseId, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if !kermesse.IsEditable() {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse ne peut plus être modifiée"),
+		}
+	}
+
+	err = s.store.Create(input)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Update(ctx context.Context, id int, input map[string]interface{}) error {
+	tombola, err := s.store.FindById(id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
@@ -87,28 +126,19 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
-	if kermesse.Statut == types.KermesseStatutEnded {
-		return errors.CustomError{
-			Key: errors.BadRequest,
-			Err: goErrors.New("La kermesse est terminée"),
-		}
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, tombola.KermesseId, types.KermessePermissionManage)
+	if err != nil {
+		return err
 	}
 
-	userId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
-		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
-		}
-	}
-	if kermesse.UserId != userId {
+	if !kermesse.IsEditable() {
 		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit"),
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse ne peut plus être modifiée"),
 		}
 	}
 
-	err = s.store.Create(input)
+	err = s.store.Update(id, input)
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
@@ -119,7 +149,8 @@ func (s *Service) Create(ctx context.Context, input map[string]interface{}) erro
 	return nil
 }
 
-func (s *Service) Update(ctx context.Context, id int, input map[string]interface{}) error {
+// Tire le gagnant avec la graine engagée avant la fin des ventes, voir Draw.
+func (s *Service) End(ctx context.Context, id int, input map[string]interface{}) error {
 	tombola, err := s.store.FindById(id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -134,7 +165,59 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 		}
 	}
 
-	kermesse, err := s.kermesseStore.FindById(tombola.KermesseId)
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, tombola.KermesseId, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if kermesse.Statut != types.KermesseStatutOpen {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La kermesse n'est pas ouverte"),
+		}
+	}
+
+	if tombola.Statut != types.TombolaStatutStarted {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La tombola est déjà terminée"),
+		}
+	}
+	if tombola.SeedHash == nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("Aucun engagement de tirage n'a été publié"),
+		}
+	}
+	seed, ok := input["seed"].(string)
+	if !ok || len(seed) > maxSeedLength || HashSeed(seed) != *tombola.SeedHash {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La graine ne correspond pas à l'engagement publié"),
+		}
+	}
+
+	drawn, err := s.store.SelectGagnant(id, seed)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !drawn {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("La tombola est déjà terminée"),
+		}
+	}
+
+	return nil
+}
+
+// Publie l'empreinte SHA-256 de la graine du tirage. L'engagement est définitif
+// et ouvre la vente des tickets.
+func (s *Service) Commit(ctx context.Context, id int, input map[string]interface{}) error {
+	tombola, err := s.store.FindById(id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
@@ -148,54 +231,100 @@ func (s *Service) Update(ctx context.Context, id int, input map[string]interface
 		}
 	}
 
-	if kermesse.Statut == types.KermesseStatutEnded {
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, tombola.KermesseId, types.KermessePermissionManage); err != nil {
+		return err
+	}
+
+	seedHash, ok := input["seed_hash"].(string)
+	if !ok || !isSeedHash(seedHash) {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("La kermesse est terminée"),
+			Err: goErrors.New("seed_hash doit être une empreinte SHA-256 en hexadécimal"),
 		}
 	}
-
-	userId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
+	if tombola.Statut != types.TombolaStatutStarted {
 		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+			Key: errors.BadRequest,
+			Err: goErrors.New("La tombola est déjà terminée"),
 		}
 	}
-	if kermesse.UserId != userId {
+	if tombola.SeedHash != nil {
 		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Inrerdit"),
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'engagement du tirage ne peut plus être modifié"),
 		}
 	}
 
-	err = s.store.Update(id, input)
+	committed, err := s.store.Commit(id, seedHash)
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
+	if !committed {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("L'engagement du tirage ne peut plus être modifié"),
+		}
+	}
 
 	return nil
 }
 
-func (s *Service) End(ctx context.Context, id int) error {
+// Tout ce qu'il faut pour refaire le tirage : la graine n'est révélée qu'une fois la tombola tirée.
+func (s *Service) GetAudit(ctx context.Context, id int) (types.TombolaAudit, error) {
 	tombola, err := s.store.FindById(id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
-			return errors.CustomError{
+			return types.TombolaAudit{}, errors.CustomError{
 				Key: errors.NotFound,
 				Err: err,
 			}
 		}
-		return errors.CustomError{
+		return types.TombolaAudit{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	ticketIds, err := s.store.FindTicketIds(id)
+	if err != nil {
+		return types.TombolaAudit{}, errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	audit := types.TombolaAudit{
+		TombolaId: id,
+		Statut:    tombola.Statut,
+		Algorithm: DrawAlgorithm,
+		SeedHash:  tombola.SeedHash,
+		DrawnAt:   tombola.DrawnAt,
+		TicketIds: ticketIds,
+	}
+	if tombola.Seed == nil {
+		return audit, nil
+	}
+
+	_, digest := Draw(*tombola.Seed, ticketIds)
+	winner, err := s.store.FindWinner(id)
+	if err != nil {
+		return types.TombolaAudit{}, errors.CustomError{
 			Key: errors.InternalServerError,
 			Err: err,
 		}
 	}
+	audit.Seed = tombola.Seed
+	audit.Digest = &digest
+	audit.WinnerTicketId = winner
+
+	return audit, nil
+}
 
-	kermesse, err := s.kermesseStore.FindById(tombola.KermesseId)
+// Annule la tombola : chaque acheteur récupère le prix de ses tickets, repris à la trésorerie.
+func (s *Service) Cancel(ctx context.Context, id int) error {
+	tombola, err := s.store.FindById(id)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
 			return errors.CustomError{
@@ -209,35 +338,63 @@ func (s *Service) End(ctx context.Context, id int) error {
 		}
 	}
 
-	if kermesse.Statut == types.KermesseStatutEnded {
+	if _, err := kermesse.Authorize(ctx, s.kermesseStore, tombola.KermesseId, types.KermessePermissionManage); err != nil {
+		return err
+	}
+
+	if tombola.Statut != types.TombolaStatutStarted {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("La kermeese est terminée"),
+			Err: goErrors.New("La tombola est déjà terminée"),
 		}
 	}
 
-	userId, ok := ctx.Value(types.UserIDKey).(int)
-	if !ok {
+	cancelled, err := s.store.Cancel(id)
+	if err != nil {
 		return errors.CustomError{
-			Key: errors.Unauthorized,
-			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+			Key: errors.InternalServerError,
+			Err: err,
 		}
 	}
-	if kermesse.UserId != userId {
+	if !cancelled {
 		return errors.CustomError{
-			Key: errors.Forbidden,
-			Err: goErrors.New("Interdit"),
+			Key: errors.BadRequest,
+			Err: goErrors.New("La tombola est déjà terminée"),
 		}
 	}
 
-	if tombola.Statut == types.TombolaStatutEnded {
+	return nil
+}
+
+// Les tickets ne sont vendus qu'à l'ouverture : une tombola supprimable n'en a aucun.
+func (s *Service) Delete(ctx context.Context, id int) error {
+	tombola, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	kermesse, err := kermesse.Authorize(ctx, s.kermesseStore, tombola.KermesseId, types.KermessePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if !kermesse.IsEditable() {
 		return errors.CustomError{
 			Key: errors.BadRequest,
-			Err: goErrors.New("La tombola est déjà terminée"),
+			Err: goErrors.New("La kermesse ne peut plus être modifiée"),
 		}
 	}
 
-	err = s.store.SelectGagnant(id)
+	err = s.store.Delete(id)
 	if err != nil {
 		return errors.CustomError{
 			Key: errors.InternalServerError,
@@ -0,0 +1,209 @@
+package tombola
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"testing"
+
+	"github.com/chall-goflutter-api/internal/kermesse"
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+)
+
+type fakeTombolaStore struct {
+	TombolaStore
+	tombola   types.Tombola
+	cancelled int
+	drawnWith *string
+}
+
+func (f *fakeTombolaStore) FindById(id int) (types.Tombola, error) {
+	if id != f.tombola.Id {
+		return types.Tombola{}, sql.ErrNoRows
+	}
+	return f.tombola, nil
+}
+
+func (f *fakeTombolaStore) Cancel(id int) (bool, error) {
+	if f.tombola.Statut != types.TombolaStatutStarted {
+		return false, nil
+	}
+	f.tombola.Statut = types.TombolaStatutCancelled
+	f.cancelled++
+	return true, nil
+}
+
+func (f *fakeTombolaStore) SelectGagnant(id int, seed string) (bool, error) {
+	if f.tombola.Statut != types.TombolaStatutStarted {
+		return false, nil
+	}
+	f.tombola.Statut = types.TombolaStatutEnded
+	f.drawnWith = &seed
+	return true, nil
+}
+
+func (f *fakeTombolaStore) Commit(id int, seedHash string) (bool, error) {
+	if f.tombola.SeedHash != nil {
+		return false, nil
+	}
+	f.tombola.SeedHash = &seedHash
+	return true, nil
+}
+
+type fakeKermesseStore struct {
+	kermesse.KermesseStore
+	role string
+}
+
+func (f *fakeKermesseStore) FindById(id int) (types.Kermesse, error) {
+	return types.Kermesse{Id: id, Statut: types.KermesseStatutOpen}, nil
+}
+
+func (f *fakeKermesseStore) FindMemberRole(id int, userId int) (string, error) {
+	if f.role == "" {
+		return "", sql.ErrNoRows
+	}
+	return f.role, nil
+}
+
+func cancel(statut string, role string) (*fakeTombolaStore, error) {
+	store := &fakeTombolaStore{tombola: types.Tombola{Id: 3, KermesseId: 7, Statut: statut, Price: 4}}
+	service := NewService(store, &fakeKermesseStore{role: role})
+	ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+
+	return store, service.Cancel(ctx, 3)
+}
+
+func errorKey(err error) string {
+	var customErr errors.CustomError
+	if goErrors.As(err, &customErr) {
+		return customErr.Key
+	}
+	return ""
+}
+
+func TestCancel(t *testing.T) {
+	store, err := cancel(types.TombolaStatutStarted, types.KermesseMemberCoOrganisateur)
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if store.cancelled != 1 {
+		t.Errorf("%d annulations, attendu 1", store.cancelled)
+	}
+	if store.tombola.Statut != types.TombolaStatutCancelled {
+		t.Errorf("statut = %s, attendu %s", store.tombola.Statut, types.TombolaStatutCancelled)
+	}
+}
+
+func TestCancelRefused(t *testing.T) {
+	tests := []struct {
+		name    string
+		statut  string
+		role    string
+		wantKey string
+	}{
+		{"caissier", types.TombolaStatutStarted, types.KermesseMemberCaissier, errors.Forbidden},
+		{"hors équipe", types.TombolaStatutStarted, "", errors.Forbidden},
+		{"tombola tirée", types.TombolaStatutEnded, types.KermesseMemberOwner, errors.BadRequest},
+		{"tombola déjà annulée", types.TombolaStatutCancelled, types.KermesseMemberOwner, errors.BadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := cancel(tt.statut, tt.role)
+			if errorKey(err) != tt.wantKey {
+				t.Fatalf("erreur = %v, attendu %s", err, tt.wantKey)
+			}
+			if store.cancelled != 0 {
+				t.Errorf("la tombola ne doit pas être annulée")
+			}
+		})
+	}
+}
+
+func end(seedHash *string, input map[string]interface{}) (*fakeTombolaStore, error) {
+	store := &fakeTombolaStore{tombola: types.Tombola{Id: 3, KermesseId: 7, Statut: types.TombolaStatutStarted, SeedHash: seedHash}}
+	service := NewService(store, &fakeKermesseStore{role: types.KermesseMemberOwner})
+	ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+
+	return store, service.End(ctx, 3, input)
+}
+
+func TestEndRevealsCommittedSeed(t *testing.T) {
+	seedHash := HashSeed("kermesse-2026")
+	store, err := end(&seedHash, map[string]interface{}{"seed": "kermesse-2026"})
+	if err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if store.drawnWith == nil || *store.drawnWith != "kermesse-2026" {
+		t.Errorf("tirage avec la graine %v, attendu kermesse-2026", store.drawnWith)
+	}
+}
+
+func TestEndRefused(t *testing.T) {
+	seedHash := HashSeed("kermesse-2026")
+	tests := []struct {
+		name     string
+		seedHash *string
+		input    map[string]interface{}
+	}{
+		{"sans engagement", nil, map[string]interface{}{"seed": "kermesse-2026"}},
+		{"sans graine", &seedHash, map[string]interface{}{}},
+		{"autre graine", &seedHash, map[string]interface{}{"seed": "kermesse-2027"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := end(tt.seedHash, tt.input)
+			if errorKey(err) != errors.BadRequest {
+				t.Fatalf("erreur = %v, attendu %s", err, errors.BadRequest)
+			}
+			if store.drawnWith != nil {
+				t.Errorf("la tombola ne doit pas être tirée")
+			}
+		})
+	}
+}
+
+// Annulée entre la lecture de la tombola et le tirage : le store refuse de la tirer.
+func TestEndAfterConcurrentCancel(t *testing.T) {
+	seedHash := HashSeed("kermesse-2026")
+	store := &fakeTombolaStore{tombola: types.Tombola{Id: 3, KermesseId: 7, Statut: types.TombolaStatutStarted, SeedHash: &seedHash}}
+	service := NewService(&cancelledOnDraw{store}, &fakeKermesseStore{role: types.KermesseMemberOwner})
+	ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+
+	if err := service.End(ctx, 3, map[string]interface{}{"seed": "kermesse-2026"}); errorKey(err) != errors.BadRequest {
+		t.Fatalf("erreur = %v, attendu %s", err, errors.BadRequest)
+	}
+	if store.drawnWith != nil || store.tombola.Statut != types.TombolaStatutCancelled {
+		t.Errorf("statut = %s, attendu %s sans tirage", store.tombola.Statut, types.TombolaStatutCancelled)
+	}
+}
+
+type cancelledOnDraw struct {
+	*fakeTombolaStore
+}
+
+func (c *cancelledOnDraw) SelectGagnant(id int, seed string) (bool, error) {
+	c.Cancel(id)
+	return c.fakeTombolaStore.SelectGagnant(id, seed)
+}
+
+func TestCommitIsFinal(t *testing.T) {
+	store := &fakeTombolaStore{tombola: types.Tombola{Id: 3, KermesseId: 7, Statut: types.TombolaStatutStarted}}
+	service := NewService(store, &fakeKermesseStore{role: types.KermesseMemberOwner})
+	ctx := context.WithValue(context.Background(), types.UserIDKey, 1)
+
+	if err := service.Commit(ctx, 3, map[string]interface{}{"seed_hash": "not-a-hash"}); errorKey(err) != errors.BadRequest {
+		t.Fatalf("erreur = %v, attendu %s", err, errors.BadRequest)
+	}
+	first := HashSeed("kermesse-2026")
+	if err := service.Commit(ctx, 3, map[string]interface{}{"seed_hash": first}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := service.Commit(ctx, 3, map[string]interface{}{"seed_hash": HashSeed("kermesse-2027")}); errorKey(err) != errors.BadRequest {
+		t.Fatalf("erreur = %v, attendu %s", err, errors.BadRequest)
+	}
+	if store.tombola.SeedHash == nil || *store.tombola.SeedHash != first {
+		t.Errorf("engagement = %v, attendu %s", store.tombola.SeedHash, first)
+	}
+}
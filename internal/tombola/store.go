@@ -1,8 +1,11 @@
 package tombola
 
 import (
+	"database/sql"
+	goErrors "errors"
 	"fmt"
 
+	"github.com/chall-goflutter-api/internal/kermesse"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/jmoiron/sqlx"
 )
@@ -12,7 +15,12 @@ type TombolaStore interface {
 	FindById(id int) (types.Tombola, error)
 	Create(input map[string]interface{}) error
 	Update(id int, input map[string]interface{}) error
-	SelectGagnant(id int) error
+	SelectGagnant(id int, seed string) (bool, error)
+	Commit(id int, seedHash string) (bool, error)
+	FindTicketIds(id int) ([]int, error)
+	FindWinner(id int) (*int, error)
+	Cancel(id int) (bool, error)
+	Delete(id int) error
 }
 
 type Store struct {
@@ -26,10 +34,19 @@ func NewStore(db *sqlx.DB) *Store {
 }
 
 const (
-	queryFindTombolaById = "SELECT * FROM tombolas WHERE id=$1"
+	queryFindTombolaById = "SELECT * FROM tombolas WHERE id=$1 AND deleted_at IS NULL"
 	queryCreateTombola   = "INSERT INTO tombolas (kermesse_id, name, price, lot) VALUES ($1, $2, $3, $4)"
 	queryUpdateTombola   = "UPDATE tombolas SET name=$1, price=$2, lot=$3 WHERE id=$4"
 	queryUpdateStatut    = "UPDATE tombolas SET statut=$1 WHERE id=$2"
+	queryDeleteTombola   = "UPDATE tombolas SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL"
+	queryCancelTombola   = "UPDATE tombolas SET statut=$1 WHERE id=$2 AND statut=$3 RETURNING kermesse_id"
+	queryCommitTombola   = "UPDATE tombolas SET seed_hash=$1 WHERE id=$2 AND statut=$3 AND seed_hash IS NULL AND NOT EXISTS (SELECT 1 FROM tickets WHERE tombola_id=$2)"
+	queryFindTicketIds   = "SELECT id FROM tickets WHERE tombola_id=$1 ORDER BY id"
+	queryFindWinner      = "SELECT id FROM tickets WHERE tombola_id=$1 AND gagnant LIMIT 1"
+	queryDrawTombola     = "UPDATE tombolas SET statut=$1, seed=$2, drawn_at=NOW() WHERE id=$3 AND statut=$4"
+	queryMarkWinner      = "UPDATE tickets SET gagnant=true WHERE id=$1 AND tombola_id=$2"
+	querySoldTickets     = "SELECT id, user_id, price FROM tickets WHERE tombola_id=$1 ORDER BY id"
+	queryRefundBuyer     = "UPDATE users SET jetons=jetons+$1 WHERE id=$2"
 )
 
 func (s *Store) FindAll(filters map[string]interface{}) ([]types.Tombola, error) {
@@ -41,14 +58,25 @@ func (s *Store) FindAll(filters map[string]interface{}) ([]types.Tombola, error)
 			t.name AS name,
 			t.statut AS statut,
 			t.price AS price,
-			t.lot AS lot
+			t.lot AS lot,
+			t.seed_hash AS seed_hash,
+			t.seed AS seed,
+			t.drawn_at AS drawn_at
 		FROM tombolas t
-		WHERE 1=1
+		WHERE t.deleted_at IS NULL
 	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filters["organisation_id"] != nil {
+		query += fmt.Sprintf(" AND t.kermesse_id IN (SELECT id FROM kermesses WHERE organisation_id = %s)", arg(filters["organisation_id"]))
+	}
 	if filters["kermesse_id"] != nil {
-		query += fmt.Sprintf(" AND t.kermesse_id = %v", filters["kermesse_id"])
+		query += fmt.Sprintf(" AND t.kermesse_id = %s", arg(filters["kermesse_id"]))
 	}
-	err := s.db.Select(&tombolas, query)
+	err := s.db.Select(&tombolas, query, args...)
 	return tombolas, err
 }
 
@@ -71,11 +99,88 @@ func (s *Store) Update(id int, input map[string]interface{}) error {
 	return err
 }
 
-// Sélectionne un gagnant aléatoire parmi les tickets d'une tombola et termine la tombola.
-func (s *Store) SelectGagnant(id int) error {
+// Termine la tombola en révélant la graine et marque le ticket désigné par Draw.
+// Les tickets sont relus dans la transaction, après la fin des ventes.
+// Renvoie false si la tombola n'était plus en cours, par exemple annulée entre-temps.
+func (s *Store) SelectGagnant(id int, seed string) (drawn bool, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	result, err := tx.Exec(queryDrawTombola, types.TombolaStatutEnded, seed, id, types.TombolaStatutStarted)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	ticketIds := []int{}
+	if err = tx.Select(&ticketIds, queryFindTicketIds, id); err != nil {
+		return false, err
+	}
+	winner, _ := Draw(seed, ticketIds)
+	if winner == 0 {
+		return true, nil
+	}
+	if _, err = tx.Exec(queryMarkWinner, winner, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Renvoie false si la tombola n'est plus en cours, si l'engagement existe déjà
+// ou si des tickets ont été vendus avant lui.
+func (s *Store) Commit(id int, seedHash string) (bool, error) {
+	result, err := s.db.Exec(queryCommitTombola, seedHash, id, types.TombolaStatutStarted)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+
+	return rows > 0, err
+}
+
+func (s *Store) FindTicketIds(id int) ([]int, error) {
+	ticketIds := []int{}
+	err := s.db.Select(&ticketIds, queryFindTicketIds, id)
+
+	return ticketIds, err
+}
+
+func (s *Store) FindWinner(id int) (*int, error) {
+	var winner int
+	err := s.db.QueryRow(queryFindWinner, id).Scan(&winner)
+	if goErrors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &winner, nil
+}
+
+// Annule la tombola en cours et rembourse chaque ticket à son prix d'achat depuis la trésorerie.
+// Renvoie false si la tombola n'était plus en cours.
+func (s *Store) Cancel(id int) (cancelled bool, err error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	defer func() {
@@ -89,21 +194,35 @@ func (s *Store) SelectGagnant(id int) error {
 		}
 	}()
 
-	if _, err = tx.Exec(queryUpdateStatut, types.TombolaStatutEnded, id); err != nil {
-		return err
-	}
-
-	const querySelectGagnant = `
-		UPDATE tickets
-		SET gagnant = true
-		WHERE id = (
-			SELECT id
-			FROM tickets
-			WHERE tombola_id = $1
-			ORDER BY RANDOM()
-			LIMIT 1
-		)
-		AND tombola_id = $1`
-	_, err = tx.Exec(querySelectGagnant, id)
+	var kermesseId int
+	err = tx.QueryRow(queryCancelTombola, types.TombolaStatutCancelled, id, types.TombolaStatutStarted).Scan(&kermesseId)
+	if goErrors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	tickets := []soldTicket{}
+	if err = tx.Select(&tickets, querySoldTickets, id); err != nil {
+		return false, err
+	}
+	for _, r := range refunds(tickets) {
+		if _, err = tx.Exec(queryRefundBuyer, r.Amount, r.UserId); err != nil {
+			return false, err
+		}
+	}
+	for _, entry := range refundEntries(kermesseId, tickets) {
+		if err = kermesse.RecordTreasury(tx, entry); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Store) Delete(id int) error {
+	_, err := s.db.Exec(queryDeleteTombola, id)
+
 	return err
 }
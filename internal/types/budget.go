@@ -0,0 +1,61 @@
+package types
+
+import "time"
+
+const (
+	ExpenseCategoryStock    string = "STOCK"
+	ExpenseCategoryLots     string = "LOTS"
+	ExpenseCategoryLocation string = "LOCATION"
+	ExpenseCategoryAutre    string = "AUTRE"
+)
+
+const (
+	PaymentSourceStripe string = "STRIPE"
+	PaymentSourceCash   string = "CASH"
+)
+
+// Dépense d'une kermesse, Amount en centimes d'euro.
+type Expense struct {
+	Id         int       `json:"id" db:"id"`
+	KermesseId int       `json:"kermesse_id" db:"kermesse_id"`
+	UserId     int       `json:"user_id" db:"user_id"`
+	Category   string    `json:"category" db:"category"`
+	Label      string    `json:"label" db:"label"`
+	Amount     int       `json:"amount" db:"amount"`
+	SpentAt    time.Time `json:"spent_at" db:"spent_at"`
+	ReceiptKey *string   `json:"-" db:"receipt_key"`
+	ReceiptUrl *string   `json:"receipt_url" db:"-"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// Achat de jetons payé en euros, Amount en centimes d'euro.
+type Payment struct {
+	Id              int       `json:"id" db:"id"`
+	KermesseId      *int      `json:"kermesse_id" db:"kermesse_id"`
+	UserId          *int      `json:"user_id" db:"user_id"`
+	RecordedBy      *int      `json:"recorded_by" db:"recorded_by"`
+	Source          string    `json:"source" db:"source"`
+	Amount          int       `json:"amount" db:"amount"`
+	Jetons          int       `json:"jetons" db:"jetons"`
+	StripeSessionId *string   `json:"-" db:"stripe_session_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// Compte de résultat de la kermesse, montants en centimes d'euro.
+type BudgetReport struct {
+	KermesseId         int            `json:"kermesse_id"`
+	StripeIncome       int            `json:"stripe_income" db:"stripe_income"`
+	CashIncome         int            `json:"cash_income" db:"cash_income"`
+	Income             int            `json:"income"`
+	Expenses           int            `json:"expenses"`
+	ExpensesByCategory map[string]int `json:"expenses_by_category"`
+	Profit             int            `json:"profit"`
+}
+
+func IsExpenseCategory(category string) bool {
+	switch category {
+	case ExpenseCategoryStock, ExpenseCategoryLots, ExpenseCategoryLocation, ExpenseCategoryAutre:
+		return true
+	}
+	return false
+}
@@ -7,6 +7,9 @@ const (
 	InteractionTypeActivite    string = "ACTIVITE"
 	InteractionStatutStarted   string = "STARTED"
 	InteractionStatutEnded     string = "ENDED"
+	InteractionSyncAccepted    string = "ACCEPTED"
+	InteractionSyncDuplicate   string = "DUPLICATE"
+	InteractionSyncRejected    string = "REJECTED"
 )
 
 type InteractionUser struct {
@@ -32,24 +35,67 @@ type InteractionKermesse struct {
 }
 
 type Interaction struct {
-	Id        int                 `json:"id" db:"id"`
-	Type      string              `json:"type" db:"type"`
-	Statut    string              `json:"statut" db:"statut"`
-	Jetons    int                 `json:"jetons" db:"jetons"`
-	Points    int                 `json:"points" db:"points"`
-	CreatedAt time.Time           `json:"created_at" db:"created_at"`
-	User      InteractionUser     `json:"user" db:"user"`
-	Stand     InteractionStand    `json:"stand" db:"stand"`
-	Kermesse  InteractionKermesse `json:"kermesse" db:"kermesse"`
+	Id          int                 `json:"id" db:"id"`
+	Type        string              `json:"type" db:"type"`
+	Statut      string              `json:"statut" db:"statut"`
+	Jetons      int                 `json:"jetons" db:"jetons"`
+	Points      int                 `json:"points" db:"points"`
+	PromotionId *int                `json:"promotion_id" db:"promotion_id"`
+	Discount    int                 `json:"discount" db:"discount"`
+	Quantity    int                 `json:"quantity" db:"quantity"`
+	Commission  int                 `json:"commission" db:"commission"`
+	RefundedAt  *time.Time          `json:"refunded_at" db:"refunded_at"`
+	CreatedAt   time.Time           `json:"created_at" db:"created_at"`
+	User        InteractionUser     `json:"user" db:"user"`
+	Stand       InteractionStand    `json:"stand" db:"stand"`
+	Kermesse    InteractionKermesse `json:"kermesse" db:"kermesse"`
 }
 
 type InteractionBasic struct {
-	Id        int              `json:"id" db:"id"`
-	Type      string           `json:"type" db:"type"`
-	Statut    string           `json:"statut" db:"statut"`
-	Jetons    int              `json:"jetons" db:"jetons"`
-	Points    int              `json:"points" db:"points"`
-	CreatedAt time.Time        `json:"created_at" db:"created_at"`
-	User      InteractionUser  `json:"user" db:"user"`
-	Stand     InteractionStand `json:"stand" db:"stand"`
+	Id          int              `json:"id" db:"id"`
+	Type        string           `json:"type" db:"type"`
+	Statut      string           `json:"statut" db:"statut"`
+	Jetons      int              `json:"jetons" db:"jetons"`
+	Points      int              `json:"points" db:"points"`
+	PromotionId *int             `json:"promotion_id" db:"promotion_id"`
+	Discount    int              `json:"discount" db:"discount"`
+	Quantity    int              `json:"quantity" db:"quantity"`
+	Commission  int              `json:"commission" db:"commission"`
+	RefundedAt  *time.Time       `json:"refunded_at" db:"refunded_at"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	User        InteractionUser  `json:"user" db:"user"`
+	Stand       InteractionStand `json:"stand" db:"stand"`
+}
+
+type InteractionClosed struct {
+	Id         int `json:"id" db:"id"`
+	UserId     int `json:"user_id" db:"user_id"`
+	StandId    int `json:"stand_id" db:"stand_id"`
+	KermesseId int `json:"kermesse_id" db:"kermesse_id"`
+	Points     int `json:"points" db:"points"`
+}
+
+type InteractionSyncResult struct {
+	ClientUuid string `json:"client_uuid"`
+	Statut     string `json:"statut"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// Autorisation de paiement obtenue en ligne par l'enfant et présentée (QR code) au stand
+// hors ligne : elle permet une seule interaction, jusqu'à MaxJetons, dans la kermesse.
+type OfflineToken struct {
+	Token      string    `json:"token"`
+	ClientUuid string    `json:"client_uuid"`
+	KermesseId int       `json:"kermesse_id"`
+	MaxJetons  int       `json:"max_jetons"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Contenu signé d'une autorisation de paiement hors ligne.
+type OfflineTokenClaims struct {
+	UserId     int    `json:"user_id"`
+	KermesseId int    `json:"kermesse_id"`
+	ClientUuid string `json:"client_uuid"`
+	MaxJetons  int    `json:"max_jetons"`
+	ExpiresAt  int64  `json:"expires_at"`
 }
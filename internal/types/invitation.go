@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+type KermesseInvitation struct {
+	Id         int        `json:"id" db:"id"`
+	KermesseId int        `json:"kermesse_id" db:"kermesse_id"`
+	Code       string     `json:"code" db:"code"`
+	MaxUses    *int       `json:"max_uses" db:"max_uses"`
+	Uses       int        `json:"uses" db:"uses"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Link       string     `json:"link" db:"-"`
+}
@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+const (
+	JobRunStatutSuccess string = "SUCCESS"
+	JobRunStatutFailed  string = "FAILED"
+)
+
+type JobRun struct {
+	Id         int       `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	Statut     string    `json:"statut" db:"statut"`
+	Message    string    `json:"message" db:"message"`
+	StartedAt  time.Time `json:"started_at" db:"started_at"`
+	FinishedAt time.Time `json:"finished_at" db:"finished_at"`
+}
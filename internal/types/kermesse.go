@@ -1,22 +1,108 @@
 package types
 
+import "time"
+
 const (
-	KermesseStatutStarted string = "STARTED"
-	KermesseStatutEnded   string = "ENDED"
+	KermesseStatutDraft     string = "DRAFT"
+	KermesseStatutPublished string = "PUBLISHED"
+	KermesseStatutOpen      string = "OPEN"
+	KermesseStatutClosed    string = "CLOSED"
+	KermesseStatutArchived  string = "ARCHIVED"
 )
 
+// Changements de statut autorisés depuis chaque statut.
+var KermesseTransitions = map[string][]string{
+	KermesseStatutDraft:     {KermesseStatutPublished},
+	KermesseStatutPublished: {KermesseStatutDraft, KermesseStatutOpen},
+	KermesseStatutOpen:      {KermesseStatutClosed},
+	KermesseStatutClosed:    {KermesseStatutArchived},
+}
+
 type Kermesse struct {
-	Id          int    `json:"id" db:"id"`
-	UserId      int    `json:"user_id" db:"user_id"`
-	Name        string `json:"name" db:"name"`
-	Description string `json:"description" db:"description"`
-	Statut      string `json:"statut" db:"statut"`
+	Id                 int        `json:"id" db:"id"`
+	UserId             int        `json:"user_id" db:"user_id"`
+	OrganisationId     int        `json:"organisation_id" db:"organisation_id"`
+	Name               string     `json:"name" db:"name"`
+	Description        string     `json:"description" db:"description"`
+	Statut             string     `json:"statut" db:"statut"`
+	StartsAt           *time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt             *time.Time `json:"ends_at" db:"ends_at"`
+	IsTemplate         bool       `json:"is_template" db:"is_template"`
+	CommissionRate     int        `json:"commission_rate" db:"commission_rate"`
+	TreasuryJetons     int        `json:"-" db:"treasury_jetons"`
+	ArchivedAt         *time.Time `json:"archived_at" db:"archived_at"`
+	AnonymisedAt       *time.Time `json:"anonymised_at" db:"anonymised_at"`
+	DeletedAt          *time.Time `json:"-" db:"deleted_at"`
+	BannerKey          *string    `json:"-" db:"banner_key"`
+	BannerThumbnailKey *string    `json:"-" db:"banner_thumbnail_key"`
+	BannerUrl          *string    `json:"banner_url" db:"-"`
+	BannerThumbnailUrl *string    `json:"banner_thumbnail_url" db:"-"`
+	MapKey             *string    `json:"-" db:"map_key"`
+	MapThumbnailKey    *string    `json:"-" db:"map_thumbnail_key"`
+	MapUrl             *string    `json:"map_url" db:"-"`
+	MapThumbnailUrl    *string    `json:"map_thumbnail_url" db:"-"`
+}
+
+// Un modèle reste en brouillon, il ne sert qu'à être cloné.
+func (k Kermesse) CanTransition(statut string) bool {
+	if k.IsTemplate {
+		return false
+	}
+	for _, next := range KermesseTransitions[k.Statut] {
+		if next == statut {
+			return true
+		}
+	}
+	return false
+}
+
+// Les informations de la kermesse ne sont modifiables qu'avant son ouverture.
+func (k Kermesse) IsEditable() bool {
+	return k.Statut == KermesseStatutDraft || k.Statut == KermesseStatutPublished
+}
+
+func (k Kermesse) IsFinished() bool {
+	return k.Statut == KermesseStatutClosed || k.Statut == KermesseStatutArchived
 }
+
+type KermesseEnd struct {
+	Id                 int                 `json:"id"`
+	ClosedInteractions []InteractionClosed `json:"closed_interactions"`
+}
+
+type KermesseClone struct {
+	Kermesse      Kermesse `json:"kermesse"`
+	SkippedStands []int    `json:"skipped_stands"`
+}
+
+// Plan de la kermesse avec l'emplacement et l'état en direct de chaque stand.
+type KermesseMap struct {
+	KermesseId      int                `json:"kermesse_id"`
+	MapUrl          *string            `json:"map_url"`
+	MapThumbnailUrl *string            `json:"map_thumbnail_url"`
+	Stands          []KermesseMapStand `json:"stands"`
+}
+
+type KermesseMapStand struct {
+	StandId     int      `json:"stand_id" db:"stand_id"`
+	Name        string   `json:"name" db:"name"`
+	Type        string   `json:"type" db:"type"`
+	Category    *string  `json:"category" db:"category"`
+	Stock       int      `json:"-" db:"stock"`
+	Capacity    *int     `json:"capacity" db:"capacity"`
+	PosX        *float64 `json:"pos_x" db:"pos_x"`
+	PosY        *float64 `json:"pos_y" db:"pos_y"`
+	Zone        *string  `json:"zone" db:"zone"`
+	QueueLength *int     `json:"queue_length" db:"queue_length"`
+	IsOpen      bool     `json:"is_open" db:"-"`
+}
+
 type KermesseStats struct {
 	UserCount         int `json:"user_count"`
 	StandCount        int `json:"stand_count"`
 	InteractionCount  int `json:"interaction_count"`
 	InteractionIncome int `json:"interaction_income"`
+	CommissionIncome  int `json:"commission_income"`
 	TicketCount       int `json:"ticket_count"`
 	TombolaIncome     int `json:"tombola_income"`
 	PointsLadder      int `json:"points"`
@@ -0,0 +1,46 @@
+package types
+
+import "time"
+
+const (
+	KermesseMemberOwner          string = "OWNER"
+	KermesseMemberCoOrganisateur string = "CO_ORGANISATEUR"
+	KermesseMemberCaissier       string = "CAISSIER"
+	KermesseMemberLecteur        string = "LECTEUR"
+)
+
+const (
+	KermessePermissionView   string = "VIEW"   // consulter la kermesse et ses statistiques
+	KermessePermissionCash   string = "CASH"   // gérer l'argent et les jetons
+	KermessePermissionManage string = "MANAGE" // configurer et piloter la kermesse
+	KermessePermissionOwn    string = "OWN"    // gérer l'équipe
+)
+
+var kermesseRolePermissions = map[string][]string{
+	KermesseMemberOwner:          {KermessePermissionView, KermessePermissionCash, KermessePermissionManage, KermessePermissionOwn},
+	KermesseMemberCoOrganisateur: {KermessePermissionView, KermessePermissionCash, KermessePermissionManage},
+	KermesseMemberCaissier:       {KermessePermissionView, KermessePermissionCash},
+	KermesseMemberLecteur:        {KermessePermissionView},
+}
+
+func IsKermesseMemberRole(role string) bool {
+	_, ok := kermesseRolePermissions[role]
+	return ok
+}
+
+func KermesseRoleCan(role string, permission string) bool {
+	for _, p := range kermesseRolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type KermesseMember struct {
+	Id         int       `json:"id" db:"id"`
+	KermesseId int       `json:"kermesse_id" db:"kermesse_id"`
+	Role       string    `json:"role" db:"role"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	User       UserBasic `json:"user" db:"user"`
+}
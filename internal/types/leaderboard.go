@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+const (
+	LeaderboardGroupChild  string = "child"
+	LeaderboardGroupClass  string = "class"
+	LeaderboardGroupFamily string = "family"
+
+	LeaderboardPrivacyInitials string = "initials" // prénom et initiale du nom
+	LeaderboardPrivacyFull     string = "full"
+)
+
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank" db:"rank"`
+	Id         *int    `json:"id" db:"id"`
+	Name       string  `json:"name" db:"name"`
+	Class      *string `json:"class" db:"class"`
+	Points     int     `json:"points" db:"points"`
+	Activities int     `json:"activities" db:"activities"`
+	Members    int     `json:"members" db:"members"`
+}
+
+type Leaderboard struct {
+	KermesseId int                `json:"kermesse_id"`
+	GroupBy    string             `json:"group_by"`
+	Privacy    string             `json:"privacy"`
+	Entries    []LeaderboardEntry `json:"entries"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
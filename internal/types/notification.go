@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+const (
+	NotificationTypeLowStock      string = "LOW_STOCK"
+	NotificationTypeKermesseClose string = "KERMESSE_CLOSE"
+	NotificationTypeShiftReminder string = "SHIFT_REMINDER"
+)
+
+type Notification struct {
+	Id        int       `json:"id" db:"id"`
+	UserId    int       `json:"user_id" db:"user_id"`
+	Type      string    `json:"type" db:"type"`
+	Message   string    `json:"message" db:"message"`
+	IsRead    bool      `json:"is_read" db:"is_read"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,11 @@
+package types
+
+import "time"
+
+// École hébergée par l'API. Utilisateurs, kermesses et stands appartiennent à une seule école.
+type Organisation struct {
+	Id        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type PaymentRequest struct {
+	Id         int        `json:"id" db:"id"`
+	StandId    int        `json:"stand_id" db:"stand_id"`
+	KermesseId int        `json:"kermesse_id" db:"kermesse_id"`
+	UserId     int        `json:"user_id" db:"user_id"`
+	Quantity   int        `json:"quantity" db:"quantity"`
+	Amount     int        `json:"amount" db:"amount"`
+	Nonce      string     `json:"-" db:"nonce"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt     *time.Time `json:"used_at" db:"used_at"`
+	UsedBy     *int       `json:"used_by" db:"used_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Payload    string     `json:"payload" db:"-"`
+}
+
+// Contenu signé encodé dans le QR code d'une demande de paiement.
+type PaymentRequestClaims struct {
+	Id         int    `json:"id"`
+	StandId    int    `json:"stand_id"`
+	KermesseId int    `json:"kermesse_id"`
+	Quantity   int    `json:"quantity"`
+	Amount     int    `json:"amount"`
+	Nonce      string `json:"nonce"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
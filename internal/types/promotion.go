@@ -0,0 +1,57 @@
+package types
+
+import "time"
+
+const (
+	PromotionTypePercent string = "PERCENT"
+	PromotionTypeFixed   string = "FIXED"
+	PromotionTypeBundle  string = "BUNDLE"
+)
+
+type Promotion struct {
+	Id             int        `json:"id" db:"id"`
+	StandId        int        `json:"stand_id" db:"stand_id"`
+	KermesseId     *int       `json:"kermesse_id" db:"kermesse_id"`
+	Name           string     `json:"name" db:"name"`
+	Type           string     `json:"type" db:"type"`
+	Value          int        `json:"value" db:"value"`
+	BundleQuantity int        `json:"bundle_quantity" db:"bundle_quantity"`
+	BundlePrice    int        `json:"bundle_price" db:"bundle_price"`
+	StartsAt       *time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at" db:"ends_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Prix total de quantity articles à unitPrice jetons avec la promotion appliquée.
+func (p Promotion) Apply(unitPrice, quantity int) int {
+	switch p.Type {
+	case PromotionTypePercent:
+		// Arrondi au jeton supérieur
+		return (unitPrice*quantity*(100-p.Value) + 99) / 100
+	case PromotionTypeFixed:
+		return max(unitPrice-p.Value, 0) * quantity
+	case PromotionTypeBundle:
+		if p.BundleQuantity <= 0 {
+			return unitPrice * quantity
+		}
+		return (quantity/p.BundleQuantity)*p.BundlePrice + (quantity%p.BundleQuantity)*unitPrice
+	default:
+		return unitPrice * quantity
+	}
+}
+
+// Retient la promotion la plus avantageuse pour l'acheteur parmi celles fournies.
+// Renvoie le prix sans promotion si aucune ne fait baisser le prix.
+func BestPromotion(unitPrice, quantity int, promotions []Promotion) (int, *Promotion) {
+	total := unitPrice * quantity
+	var best *Promotion
+	for i := range promotions {
+		price := promotions[i].Apply(unitPrice, quantity)
+		if price < total {
+			total = price
+			best = &promotions[i]
+		}
+	}
+
+	return total, best
+}
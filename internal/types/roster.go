@@ -0,0 +1,25 @@
+package types
+
+const (
+	RosterStatutCreated  string = "CREATED"
+	RosterStatutExisting string = "EXISTING"
+)
+
+// Résultat de l'import d'une ligne du fichier. En simulation, CREATED signifie "serait créé".
+type RosterRow struct {
+	Line         int    `json:"line"`
+	ParentEmail  string `json:"parent_email"`
+	ChildName    string `json:"child_name"`
+	ParentStatut string `json:"parent_statut,omitempty"`
+	ChildStatut  string `json:"child_statut,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type RosterImport struct {
+	DryRun   bool        `json:"dry_run"`
+	Total    int         `json:"total"`
+	Imported int         `json:"imported"`
+	Failed   int         `json:"failed"`
+	Invited  int         `json:"invited"`
+	Rows     []RosterRow `json:"rows"`
+}
@@ -0,0 +1,36 @@
+package types
+
+import "time"
+
+// Créneau de bénévoles sur un stand. Missing est le nombre de places encore libres.
+type Shift struct {
+	Id         int              `json:"id" db:"id"`
+	KermesseId int              `json:"kermesse_id" db:"kermesse_id"`
+	StandId    int              `json:"stand_id" db:"stand_id"`
+	StandName  string           `json:"stand_name" db:"stand_name"`
+	StartsAt   time.Time        `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time        `json:"ends_at" db:"ends_at"`
+	Headcount  int              `json:"headcount" db:"headcount"`
+	Filled     int              `json:"filled" db:"filled"`
+	Missing    int              `json:"missing" db:"-"`
+	SignedUp   bool             `json:"signed_up" db:"-"`
+	Volunteers []ShiftVolunteer `json:"volunteers,omitempty" db:"-"`
+}
+
+type ShiftVolunteer struct {
+	ShiftId   int       `json:"-" db:"shift_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	User      UserBasic `json:"user" db:"user"`
+}
+
+// Rappel à envoyer à un bénévole avant son créneau.
+type ShiftReminder struct {
+	Id           int       `db:"id"`
+	UserId       int       `db:"user_id"`
+	UserName     string    `db:"user_name"`
+	UserEmail    string    `db:"user_email"`
+	KermesseName string    `db:"kermesse_name"`
+	StandName    string    `db:"stand_name"`
+	StartsAt     time.Time `db:"starts_at"`
+	EndsAt       time.Time `db:"ends_at"`
+}
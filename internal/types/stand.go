@@ -1,16 +1,152 @@
 package types
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chall-goflutter-api/pkg/utils"
+	"github.com/lib/pq"
+)
+
 const (
 	StandTypeVente    string = "VENTE"
 	StandTypeActivite string = "ACTIVITE"
 )
 
+const (
+	StandCategoryNourriture string = "NOURRITURE"
+	StandCategoryBoisson    string = "BOISSON"
+	StandCategoryJeu        string = "JEU"
+	StandCategoryAtelier    string = "ATELIER"
+	StandCategoryArtisanat  string = "ARTISANAT"
+	StandCategoryAutre      string = "AUTRE"
+)
+
+const (
+	StandScoringRange   string = "RANGE"
+	StandScoringTiers   string = "TIERS"
+	StandScoringMapping string = "MAPPING"
+)
+
 type Stand struct {
-	Id          int    `json:"id" db:"id"`
-	UserId      int    `json:"user_id" db:"user_id"`
-	Name        string `json:"name" db:"name"`
-	Description string `json:"description" db:"description"`
-	Type        string `json:"type" db:"type"`
-	Price       int    `json:"price" db:"price"`
-	Stock       int    `json:"stock" db:"stock"`
+	Id                  int            `json:"id" db:"id"`
+	UserId              int            `json:"user_id" db:"user_id"`
+	OrganisationId      int            `json:"organisation_id" db:"organisation_id"`
+	Name                string         `json:"name" db:"name"`
+	Description         string         `json:"description" db:"description"`
+	Type                string         `json:"type" db:"type"`
+	Price               int            `json:"price" db:"price"`
+	Stock               int            `json:"stock" db:"stock"`
+	Scoring             *StandScoring  `json:"scoring" db:"scoring"`
+	TimeoutMinutes      *int           `json:"timeout_minutes" db:"timeout_minutes"`
+	TimeoutPoints       int            `json:"timeout_points" db:"timeout_points"`
+	Capacity            *int           `json:"capacity" db:"capacity"`
+	LowStockThreshold   *int           `json:"low_stock_threshold" db:"low_stock_threshold"`
+	Occupancy           int            `json:"occupancy" db:"occupancy"`
+	Category            *string        `json:"category" db:"category"`
+	Tags                pq.StringArray `json:"tags" db:"tags"`
+	ProductName         *string        `json:"product_name" db:"product_name"`
+	ImageKey            *string        `json:"-" db:"image_key"`
+	ThumbnailKey        *string        `json:"-" db:"thumbnail_key"`
+	ProductImageKey     *string        `json:"-" db:"product_image_key"`
+	ProductThumbnailKey *string        `json:"-" db:"product_thumbnail_key"`
+	DeletedAt           *time.Time     `json:"-" db:"deleted_at"`
+	ImageUrl            *string        `json:"image_url" db:"-"`
+	ThumbnailUrl        *string        `json:"thumbnail_url" db:"-"`
+	ProductImageUrl     *string        `json:"product_image_url" db:"-"`
+	ProductThumbnailUrl *string        `json:"product_thumbnail_url" db:"-"`
+}
+
+// Stand trouvé par la recherche, avec sa pertinence.
+type StandSearchResult struct {
+	Stand
+	Rank float64 `json:"rank" db:"rank"`
+}
+
+// Palier du barème MAPPING : un score supérieur ou égal à Score rapporte Points.
+type StandScoringStep struct {
+	Score  int `json:"score"`
+	Points int `json:"points"`
+}
+
+// Barème de points d'un stand d'activité, stocké en JSONB.
+type StandScoring struct {
+	Mode    string             `json:"mode"`
+	Min     int                `json:"min,omitempty"`
+	Max     int                `json:"max,omitempty"`
+	Tiers   map[string]int     `json:"tiers,omitempty"`
+	Mapping []StandScoringStep `json:"mapping,omitempty"`
+}
+
+func (sc StandScoring) Value() (driver.Value, error) {
+	return json.Marshal(sc)
+}
+
+func (sc *StandScoring) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, sc)
+	case string:
+		return json.Unmarshal([]byte(v), sc)
+	default:
+		return fmt.Errorf("cannot scan %T into StandScoring", src)
+	}
+}
+
+// Calcule les points d'une activité à partir de la saisie du teneur de stand.
+// Sans barème, seuls des points positifs sont acceptés.
+func (scoring *StandScoring) Points(input map[string]interface{}) (int, error) {
+	if scoring == nil {
+		points, err := utils.GetIntFromMap(input, "points")
+		if err != nil {
+			return 0, err
+		}
+		if points < 0 {
+			return 0, errors.New("Les points ne peuvent pas être négatifs")
+		}
+		return points, nil
+	}
+
+	switch scoring.Mode {
+	case StandScoringRange:
+		points, err := utils.GetIntFromMap(input, "points")
+		if err != nil {
+			return 0, err
+		}
+		if points < scoring.Min || points > scoring.Max {
+			return 0, fmt.Errorf("Les points doivent être compris entre %d et %d", scoring.Min, scoring.Max)
+		}
+		return points, nil
+	case StandScoringTiers:
+		tier, ok := input["tier"].(string)
+		if !ok {
+			return 0, errors.New("tier est manquant ou n'est pas une chaîne de caractères")
+		}
+		points, ok := scoring.Tiers[tier]
+		if !ok {
+			return 0, fmt.Errorf("Palier inconnu : %s", tier)
+		}
+		return points, nil
+	case StandScoringMapping:
+		score, err := utils.GetIntFromMap(input, "score")
+		if err != nil {
+			return 0, err
+		}
+		// Le palier retenu est le plus haut dont le score est atteint
+		points := -1
+		for _, step := range scoring.Mapping {
+			if score >= step.Score {
+				points = step.Points
+			}
+		}
+		if points < 0 {
+			return 0, fmt.Errorf("Aucun palier pour le score %d", score)
+		}
+		return points, nil
+	default:
+		return 0, fmt.Errorf("Mode de barème inconnu : %s", scoring.Mode)
+	}
 }
@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+const (
+	StockMovementSale       string = "SALE"
+	StockMovementRestock    string = "RESTOCK"
+	StockMovementCorrection string = "CORRECTION"
+	StockMovementRefund     string = "REFUND"
+)
+
+type StockMovementUser struct {
+	Id   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	Role string `json:"role" db:"role"`
+}
+
+type StockMovement struct {
+	Id         int               `json:"id" db:"id"`
+	StandId    int               `json:"stand_id" db:"stand_id"`
+	Type       string            `json:"type" db:"type"`
+	Quantity   int               `json:"quantity" db:"quantity"`
+	StockAfter int               `json:"stock_after" db:"stock_after"`
+	Reason     string            `json:"reason" db:"reason"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+	User       StockMovementUser `json:"user" db:"user"`
+}
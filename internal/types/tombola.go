@@ -1,15 +1,35 @@
 package types
 
+import "time"
+
 const (
-	TombolaStatutStarted = "STARTED"
-	TombolaStatutEnded   = "ENDED"
+	TombolaStatutStarted   = "STARTED"
+	TombolaStatutEnded     = "ENDED"
+	TombolaStatutCancelled = "CANCELLED"
 )
 
 type Tombola struct {
-	Id         int    `json:"id" db:"id"`
-	KermesseId int    `json:"kermesse_id" db:"kermesse_id"`
-	Name       string `json:"name" db:"name"`
-	Statut     string `json:"statut" db:"statut"`
-	Price      int    `json:"price" db:"price"`
-	Lot        string `json:"lot" db:"lot"`
+	Id         int        `json:"id" db:"id"`
+	KermesseId int        `json:"kermesse_id" db:"kermesse_id"`
+	Name       string     `json:"name" db:"name"`
+	Statut     string     `json:"statut" db:"statut"`
+	Price      int        `json:"price" db:"price"`
+	Lot        string     `json:"lot" db:"lot"`
+	SeedHash   *string    `json:"seed_hash" db:"seed_hash"`
+	Seed       *string    `json:"seed" db:"seed"`
+	DrawnAt    *time.Time `json:"drawn_at" db:"drawn_at"`
+	DeletedAt  *time.Time `json:"-" db:"deleted_at"`
+}
+
+// Données publiques du tirage, de quoi recalculer le gagnant sans faire confiance au serveur.
+type TombolaAudit struct {
+	TombolaId      int        `json:"tombola_id"`
+	Statut         string     `json:"statut"`
+	Algorithm      string     `json:"algorithm"`
+	SeedHash       *string    `json:"seed_hash"`
+	Seed           *string    `json:"seed"`
+	DrawnAt        *time.Time `json:"drawn_at"`
+	TicketIds      []int      `json:"ticket_ids"`
+	Digest         *string    `json:"digest"`
+	WinnerTicketId *int       `json:"winner_ticket_id"`
 }
@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+const (
+	TreasuryTransactionCommission   string = "COMMISSION"
+	TreasuryTransactionRefund       string = "REFUND"
+	TreasuryTransactionTicket       string = "TICKET"
+	TreasuryTransactionTicketRefund string = "TICKET_REFUND"
+)
+
+// Mouvement de jetons sur le compte de trésorerie de la kermesse, Amount négatif pour un débit.
+type TreasuryTransaction struct {
+	Id            int       `json:"id" db:"id"`
+	KermesseId    int       `json:"kermesse_id" db:"kermesse_id"`
+	Type          string    `json:"type" db:"type"`
+	Amount        int       `json:"amount" db:"amount"`
+	InteractionId *int      `json:"interaction_id" db:"interaction_id"`
+	TicketId      *int      `json:"ticket_id" db:"ticket_id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+type Treasury struct {
+	KermesseId     int                   `json:"kermesse_id"`
+	CommissionRate int                   `json:"commission_rate"`
+	Jetons         int                   `json:"jetons"`
+	Transactions   []TreasuryTransaction `json:"transactions"`
+}
+
+// Part de la vente revenant à la trésorerie, arrondie à l'inférieur au profit du stand.
+func Commission(jetons int, rate int) int {
+	return jetons * rate / 100
+}
@@ -1,10 +1,14 @@
 package types
 
+import "time"
+
 type contextKey string
 
 const (
-	UserIDKey   contextKey = "userId"
-	UserRoleKey contextKey = "userRole"
+	UserIDKey         contextKey = "userId"
+	UserRoleKey       contextKey = "userRole"
+	OrganisationIDKey contextKey = "organisationId"
+	ResourceOwnersKey contextKey = "resourceOwners"
 )
 
 const (
@@ -15,13 +19,16 @@ const (
 )
 
 type User struct {
-	Id           int    `json:"id" db:"id"`
-	ParentId     *int   `json:"parentId" db:"parent_id"`
-	Name         string `json:"name" db:"name"`
-	Email        string `json:"email" db:"email"`
-	PasswordHash string `json:"password" db:"password_hash"`
-	Role         string `json:"role" db:"role"`
-	Jetons       int    `json:"jetons" db:"jetons"`
+	Id             int        `json:"id" db:"id"`
+	ParentId       *int       `json:"parentId" db:"parent_id"`
+	Name           string     `json:"name" db:"name"`
+	Email          string     `json:"email" db:"email"`
+	PasswordHash   string     `json:"password" db:"password_hash"`
+	Role           string     `json:"role" db:"role"`
+	Jetons         int        `json:"jetons" db:"jetons"`
+	Class          *string    `json:"class" db:"class"`
+	OrganisationId int        `json:"organisation_id" db:"organisation_id"`
+	DeletedAt      *time.Time `json:"-" db:"deleted_at"`
 }
 
 type UserBasic struct {
@@ -41,3 +48,9 @@ type UserBasicWithToken struct {
 	Token    string `json:"token"`
 	HasStand bool   `json:"has_stand"`
 }
+
+// Contenu signé du lien permettant de choisir son mot de passe.
+type PasswordLinkClaims struct {
+	UserId    int   `json:"user_id"`
+	ExpiresAt int64 `json:"expires_at"`
+}
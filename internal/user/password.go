@@ -0,0 +1,97 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	goErrors "errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/internal/types"
+	"github.com/chall-goflutter-api/pkg/errors"
+	"github.com/chall-goflutter-api/pkg/hasher"
+	"github.com/chall-goflutter-api/pkg/signature"
+)
+
+const passwordLinkTTL = 7 * 24 * time.Hour
+
+// Lien pour choisir son mot de passe, envoyé à la place d'un mot de passe en clair.
+// La signature porte sur le mot de passe actuel : le lien ne sert plus une fois utilisé.
+func PasswordLink(user types.User) (string, error) {
+	claims, err := json.Marshal(types.PasswordLinkClaims{
+		UserId:    user.Id,
+		ExpiresAt: time.Now().Add(passwordLinkTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(claims)
+	token := encoded + "." + signature.Sign(os.Getenv("PASSWORD_LINK_SECRET"), []byte(encoded+"."+user.PasswordHash))
+
+	return fmt.Sprintf("%s?token=%s", os.Getenv("PASSWORD_LINK_URL"), token), nil
+}
+
+func (s *Service) SetPassword(ctx context.Context, input map[string]interface{}) error {
+	token, _ := input["token"].(string)
+	password, ok := input["password"].(string)
+	if !ok || password == "" {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("password est manquant ou n'est pas une chaîne de caractères"),
+		}
+	}
+
+	invalid := errors.CustomError{
+		Key: errors.BadRequest,
+		Err: goErrors.New("Lien invalide ou expiré"),
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return invalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return invalid
+	}
+	claims := types.PasswordLinkClaims{}
+	if err := json.Unmarshal(raw, &claims); err != nil || time.Now().Unix() >= claims.ExpiresAt {
+		return invalid
+	}
+
+	user, err := s.store.FindById(claims.UserId)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return invalid
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !signature.Verify(os.Getenv("PASSWORD_LINK_SECRET"), []byte(parts[0]+"."+user.PasswordHash), parts[1]) {
+		return invalid
+	}
+
+	hashedPassword, err := hasher.Hash(password)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	err = s.store.UpdatePassword(user.Id, map[string]interface{}{
+		"new_password": hashedPassword,
+	})
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	goErrors "errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/chall-goflutter-api/internal/organisation"
 	"github.com/chall-goflutter-api/internal/types"
 	"github.com/chall-goflutter-api/pkg/errors"
 	"github.com/chall-goflutter-api/pkg/hasher"
@@ -15,33 +18,50 @@ import (
 	goJwt "github.com/golang-jwt/jwt/v5"
 )
 
+const maxClassLength = 50
+
 type UserService interface {
 	GetAll(ctx context.Context, params map[string]interface{}) ([]types.UserBasic, error)
 	GetChildren(ctx context.Context, params map[string]interface{}) ([]types.UserBasic, error)
 	Get(ctx context.Context, id int) (types.UserBasic, error)
 	UpdatePassword(ctx context.Context, id int, input map[string]interface{}) error
+	SetPassword(ctx context.Context, input map[string]interface{}) error
 	UpdateJetons(userId, credit int) error
 	Invite(ctx context.Context, input map[string]interface{}) error
 	Distribute(ctx context.Context, input map[string]interface{}) error
 	Register(ctx context.Context, input map[string]interface{}) error
 	Login(ctx context.Context, input map[string]interface{}) (types.UserBasicWithToken, error)
 	GetMe(ctx context.Context) (types.UserBasicWithToken, error)
+	Delete(ctx context.Context, id int) error
+	UpdateClass(ctx context.Context, id int, input map[string]interface{}) error
 }
 
 type Service struct {
-	store UserStore
+	store             UserStore
+	organisationStore organisation.OrganisationStore
 }
 
-func NewService(store UserStore) *Service {
+func NewService(store UserStore, organisationStore organisation.OrganisationStore) *Service {
 	return &Service{
-		store: store,
+		store:             store,
+		organisationStore: organisationStore,
 	}
 }
 
 func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]types.UserBasic, error) {
 	filtres := map[string]interface{}{}
+	if err := organisation.Scope(ctx, filtres); err != nil {
+		return nil, err
+	}
 	if params["kermesse_id"] != nil {
-		filtres["kermesse_id"] = params["kermesse_id"]
+		kermesseId, err := utils.GetIntFromParams(params, "kermesse_id")
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		filtres["kermesse_id"] = kermesseId
 	}
 
 	users, err := s.store.FindAll(filtres)
@@ -58,7 +78,14 @@ func (s *Service) GetAll(ctx context.Context, params map[string]interface{}) ([]
 func (s *Service) GetChildren(ctx context.Context, params map[string]interface{}) ([]types.UserBasic, error) {
 	filtres := map[string]interface{}{}
 	if params["kermesse_id"] != nil {
-		filtres["kermesse_id"] = params["kermesse_id"]
+		kermesseId, err := utils.GetIntFromParams(params, "kermesse_id")
+		if err != nil {
+			return nil, errors.CustomError{
+				Key: errors.BadRequest,
+				Err: err,
+			}
+		}
+		filtres["kermesse_id"] = kermesseId
 	}
 
 	userId, ok := ctx.Value(types.UserIDKey).(int)
@@ -157,6 +184,47 @@ func (s *Service) UpdatePassword(ctx context.Context, id int, input map[string]i
 	return nil
 }
 
+// Un utilisateur supprime son compte, ou un parent celui de son enfant.
+func (s *Service) Delete(ctx context.Context, id int) error {
+	user, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if user.Id != userId && (user.ParentId == nil || *user.ParentId != userId) {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	err = s.store.Delete(id)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
 func (s *Service) UpdateJetons(userId, credit int) error {
 	user, err := s.store.FindById(userId)
 	if err != nil {
@@ -198,6 +266,14 @@ func (s *Service) Invite(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
+	class, err := prepareClass(input)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
 	hashedPassword, err := hasher.Hash("securepassword123")
 	if err != nil {
 		return err
@@ -211,12 +287,19 @@ func (s *Service) Invite(ctx context.Context, input map[string]interface{}) erro
 		}
 	}
 
+	organisationId, err := organisation.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	err = s.store.Create(map[string]interface{}{
-		"name":      input["name"],
-		"email":     input["email"],
-		"password":  hashedPassword,
-		"role":      types.UserRoleEnfant,
-		"parent_id": userId,
+		"name":            input["name"],
+		"email":           input["email"],
+		"password":        hashedPassword,
+		"role":            types.UserRoleEnfant,
+		"parent_id":       userId,
+		"class":           class,
+		"organisation_id": organisationId,
 	})
 	if err != nil {
 		return errors.CustomError{
@@ -228,6 +311,55 @@ func (s *Service) Invite(ctx context.Context, input map[string]interface{}) erro
 	return nil
 }
 
+// Le parent renseigne la classe de son enfant.
+func (s *Service) UpdateClass(ctx context.Context, id int, input map[string]interface{}) error {
+	user, err := s.store.FindById(id)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return errors.CustomError{
+				Key: errors.NotFound,
+				Err: err,
+			}
+		}
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	userId, ok := ctx.Value(types.UserIDKey).(int)
+	if !ok {
+		return errors.CustomError{
+			Key: errors.Unauthorized,
+			Err: goErrors.New("ID utilisateur non trouvé dans le contexte"),
+		}
+	}
+	if user.ParentId == nil || *user.ParentId != userId {
+		return errors.CustomError{
+			Key: errors.Forbidden,
+			Err: goErrors.New("Interdit"),
+		}
+	}
+
+	class, err := prepareClass(input)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+
+	err = s.store.UpdateClass(id, class)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
 func (s *Service) Distribute(ctx context.Context, input map[string]interface{}) error {
 	childId, err := utils.GetIntFromMap(input, "child_id")
 	if err != nil {
@@ -334,6 +466,49 @@ func (s *Service) Register(ctx context.Context, input map[string]interface{}) er
 		}
 	}
 
+	// L'école est choisie à l'inscription parmi celles hébergées
+	organisationId, err := utils.GetIntFromMap(input, "organisation_id")
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: err,
+		}
+	}
+	exists, err := s.organisationStore.Exists(organisationId)
+	if err != nil {
+		return errors.CustomError{
+			Key: errors.InternalServerError,
+			Err: err,
+		}
+	}
+	if !exists {
+		return errors.CustomError{
+			Key: errors.BadRequest,
+			Err: goErrors.New("École inconnue"),
+		}
+	}
+	input["organisation_id"] = organisationId
+
+	// Seul le premier organisateur d'une école peut s'inscrire lui-même,
+	// les suivants passeraient sinon outre l'accord de l'école
+	if input["role"] == types.UserRoleOrganisateur {
+		created, err := s.store.CreateOrganisateur(input)
+		if err != nil {
+			return errors.CustomError{
+				Key: errors.InternalServerError,
+				Err: err,
+			}
+		}
+		if !created {
+			return errors.CustomError{
+				Key: errors.Forbidden,
+				Err: goErrors.New("Cette école a déjà un organisateur, il peut vous ajouter à l'équipe d'une kermesse"),
+			}
+		}
+
+		return nil
+	}
+
 	err = s.store.Create(input)
 	if err != nil {
 		return errors.CustomError{
@@ -449,3 +624,23 @@ func (s *Service) GetMe(ctx context.Context) (types.UserBasicWithToken, error) {
 		HasStand: hasStand,
 	}, nil
 }
+
+// La classe est facultative, une valeur vide l'efface.
+func prepareClass(input map[string]interface{}) (*string, error) {
+	if input["class"] == nil {
+		return nil, nil
+	}
+	class, ok := input["class"].(string)
+	if !ok {
+		return nil, goErrors.New("class doit être une chaîne de caractères")
+	}
+	class = strings.TrimSpace(class)
+	if class == "" {
+		return nil, nil
+	}
+	if len([]rune(class)) > maxClassLength {
+		return nil, fmt.Errorf("La classe ne peut pas dépasser %d caractères", maxClassLength)
+	}
+
+	return &class, nil
+}
@@ -13,9 +13,12 @@ type UserStore interface {
 	FindById(id int) (types.User, error)
 	FindByEmail(email string) (types.User, error)
 	Create(input map[string]interface{}) error
+	CreateOrganisateur(input map[string]interface{}) (bool, error)
 	UpdatePassword(id int, input map[string]interface{}) error
 	UpdateJetons(id int, amount int) error
 	HasStand(id int) (bool, error)
+	Delete(id int) error
+	UpdateClass(id int, class *string) error
 }
 
 type Store struct {
@@ -39,12 +42,20 @@ func (s *Store) FindAll(filtres map[string]interface{}) ([]types.UserBasic, erro
 			u.jetons AS jetons
 		FROM users u
 		FULL OUTER JOIN kermesses_users ku ON u.id = ku.user_id
-		WHERE 1=1
+		WHERE u.deleted_at IS NULL
 	`
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filtres["organisation_id"] != nil {
+		query += fmt.Sprintf(" AND u.organisation_id = %s", arg(filtres["organisation_id"]))
+	}
 	if filtres["kermesse_id"] != nil {
-		query += fmt.Sprintf(" AND ku.kermesse_id = %v", filtres["kermesse_id"])
+		query += fmt.Sprintf(" AND ku.kermesse_id = %s", arg(filtres["kermesse_id"]))
 	}
-	err := s.db.Select(&users, query)
+	err := s.db.Select(&users, query, args...)
 
 	return users, err
 }
@@ -60,19 +71,21 @@ func (s *Store) FindChildren(id int, filtres map[string]interface{}) ([]types.Us
 			u.jetons AS jetons
 		FROM users u
 		FULL OUTER JOIN kermesses_users ku ON u.id = ku.user_id
-		WHERE u.role=$1 AND u.parent_id=$2
+		WHERE u.role=$1 AND u.parent_id=$2 AND u.deleted_at IS NULL
 	`
+	args := []interface{}{types.UserRoleEnfant, id}
 	if filtres["kermesse_id"] != nil {
-		query += fmt.Sprintf(" AND ku.kermesse_id = %v", filtres["kermesse_id"])
+		args = append(args, filtres["kermesse_id"])
+		query += fmt.Sprintf(" AND ku.kermesse_id = $%d", len(args))
 	}
-	err := s.db.Select(&users, query, types.UserRoleEnfant, id)
+	err := s.db.Select(&users, query, args...)
 
 	return users, err
 }
 
 func (s *Store) FindById(id int) (types.User, error) {
 	user := types.User{}
-	query := "SELECT * FROM users WHERE id=$1"
+	query := "SELECT * FROM users WHERE id=$1 AND deleted_at IS NULL"
 	err := s.db.Get(&user, query, id)
 
 	return user, err
@@ -80,19 +93,55 @@ func (s *Store) FindById(id int) (types.User, error) {
 
 func (s *Store) FindByEmail(email string) (types.User, error) {
 	user := types.User{}
-	query := "SELECT * FROM users WHERE email=$1"
+	query := "SELECT * FROM users WHERE email=$1 AND deleted_at IS NULL"
 	err := s.db.Get(&user, query, email)
 
 	return user, err
 }
 
 func (s *Store) Create(input map[string]interface{}) error {
-	query := "INSERT INTO users (parent_id, name, email, password_hash, role) VALUES ($1, $2, $3, $4, $5)"
-	_, err := s.db.Exec(query, input["parent_id"], input["name"], input["email"], input["password"], input["role"])
+	query := "INSERT INTO users (parent_id, name, email, password_hash, role, class, organisation_id) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	_, err := s.db.Exec(query, input["parent_id"], input["name"], input["email"], input["password"], input["role"], input["class"], input["organisation_id"])
 
 	return err
 }
 
+// Crée le premier organisateur de l'école. Renvoie false si elle en a déjà un :
+// l'école est verrouillée pour que deux inscriptions simultanées ne passent pas toutes les deux.
+func (s *Store) CreateOrganisateur(input map[string]interface{}) (created bool, err error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.Exec("SELECT id FROM organisations WHERE id=$1 FOR UPDATE", input["organisation_id"]); err != nil {
+		return false, err
+	}
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE organisation_id=$1 AND role=$2)", input["organisation_id"], types.UserRoleOrganisateur).Scan(&exists)
+	if err != nil || exists {
+		return false, err
+	}
+	query := "INSERT INTO users (parent_id, name, email, password_hash, role, class, organisation_id) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	_, err = tx.Exec(query, nil, input["name"], input["email"], input["password"], types.UserRoleOrganisateur, nil, input["organisation_id"])
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (s *Store) UpdatePassword(id int, input map[string]interface{}) error {
 	query := "UPDATE users SET password_hash=$1 WHERE id=$2"
 	_, err := s.db.Exec(query, input["new_password"], id)
@@ -109,8 +158,24 @@ func (s *Store) UpdateJetons(id int, amount int) error {
 
 func (s *Store) HasStand(id int) (bool, error) {
 	var count int
-	query := "SELECT COUNT(*) FROM stands WHERE user_id=$1"
+	query := "SELECT COUNT(*) FROM stands WHERE user_id=$1 AND deleted_at IS NULL"
 	err := s.db.Get(&count, query, id)
 
 	return count >= 1, err
 }
+
+// Supprime l'utilisateur de façon logique, avec ses enfants.
+func (s *Store) Delete(id int) error {
+	// L'email est libéré pour permettre une nouvelle inscription
+	query := "UPDATE users SET deleted_at=NOW(), email='supprime-' || id || '@invalid' WHERE (id=$1 OR parent_id=$1) AND deleted_at IS NULL"
+	_, err := s.db.Exec(query, id)
+
+	return err
+}
+
+func (s *Store) UpdateClass(id int, class *string) error {
+	query := "UPDATE users SET class=$1 WHERE id=$2"
+	_, err := s.db.Exec(query, class, id)
+
+	return err
+}
@@ -0,0 +1,2 @@
+-- Barème de points des stands d'activité
+ALTER TABLE "stands" ADD COLUMN "scoring" JSONB DEFAULT NULL;
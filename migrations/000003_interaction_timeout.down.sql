@@ -0,0 +1,2 @@
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "timeout_points";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "timeout_minutes";
@@ -0,0 +1,3 @@
+-- Délai d'abandon des activités et points attribués à la clôture automatique
+ALTER TABLE "stands" ADD COLUMN "timeout_minutes" INTEGER DEFAULT NULL;
+ALTER TABLE "stands" ADD COLUMN "timeout_points" INTEGER NOT NULL DEFAULT 0;
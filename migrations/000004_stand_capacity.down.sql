@@ -0,0 +1 @@
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "capacity";
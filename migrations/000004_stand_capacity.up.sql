@@ -0,0 +1,2 @@
+-- Nombre maximum d'activités simultanées sur un stand
+ALTER TABLE "stands" ADD COLUMN "capacity" INTEGER DEFAULT NULL;
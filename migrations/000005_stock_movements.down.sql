@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "stock_movements";
+
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "low_stock_threshold";
+
+DROP TYPE IF EXISTS stock_movement_type_enum;
@@ -0,0 +1,26 @@
+CREATE TYPE stock_movement_type_enum AS ENUM ('SALE', 'RESTOCK', 'CORRECTION', 'REFUND');
+
+-- Seuil d'alerte de stock bas
+ALTER TABLE "stands" ADD COLUMN "low_stock_threshold" INTEGER DEFAULT NULL;
+
+--- Table: Stock movements
+CREATE TABLE "stock_movements" (
+  "id" SERIAL PRIMARY KEY,
+  "stand_id" INTEGER NOT NULL REFERENCES "stands"("id"),
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+  "type" stock_movement_type_enum NOT NULL,
+  "quantity" INTEGER NOT NULL,
+  "stock_after" INTEGER NOT NULL,
+  "reason" TEXT NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+--- Table: Notifications
+CREATE TABLE "notifications" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+  "type" VARCHAR(50) NOT NULL,
+  "message" TEXT NOT NULL,
+  "is_read" BOOLEAN NOT NULL DEFAULT FALSE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+ALTER TABLE "interactions" DROP COLUMN IF EXISTS "discount";
+ALTER TABLE "interactions" DROP COLUMN IF EXISTS "promotion_id";
+
+DROP TABLE IF EXISTS "promotions";
+
+DROP TYPE IF EXISTS promotion_type_enum;
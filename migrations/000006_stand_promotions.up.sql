@@ -0,0 +1,20 @@
+CREATE TYPE promotion_type_enum AS ENUM ('PERCENT', 'FIXED', 'BUNDLE');
+
+--- Table: Promotions
+CREATE TABLE "promotions" (
+  "id" SERIAL PRIMARY KEY,
+  "stand_id" INTEGER NOT NULL REFERENCES "stands"("id"),
+  "kermesse_id" INTEGER REFERENCES "kermesses"("id") DEFAULT NULL,
+  "name" VARCHAR(255) NOT NULL,
+  "type" promotion_type_enum NOT NULL,
+  "value" INTEGER NOT NULL DEFAULT 0,
+  "bundle_quantity" INTEGER NOT NULL DEFAULT 0,
+  "bundle_price" INTEGER NOT NULL DEFAULT 0,
+  "starts_at" TIMESTAMPTZ DEFAULT NULL,
+  "ends_at" TIMESTAMPTZ DEFAULT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Promotion appliquée à l'interaction
+ALTER TABLE "interactions" ADD COLUMN "promotion_id" INTEGER REFERENCES "promotions"("id") ON DELETE SET NULL DEFAULT NULL;
+ALTER TABLE "interactions" ADD COLUMN "discount" INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+--- Table: Payment requests
+CREATE TABLE "payment_requests" (
+  "id" SERIAL PRIMARY KEY,
+  "stand_id" INTEGER NOT NULL REFERENCES "stands"("id"),
+  "kermesse_id" INTEGER NOT NULL REFERENCES "kermesses"("id"),
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+  "quantity" INTEGER NOT NULL DEFAULT 1,
+  "amount" INTEGER NOT NULL,
+  "nonce" VARCHAR(64) UNIQUE NOT NULL,
+  "expires_at" TIMESTAMPTZ NOT NULL,
+  "used_at" TIMESTAMPTZ DEFAULT NULL,
+  "used_by" INTEGER REFERENCES "users"("id") DEFAULT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
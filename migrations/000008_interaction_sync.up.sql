@@ -0,0 +1,2 @@
+-- Identifiant généré par l'appareil du stand pour rejouer les ventes hors ligne sans doublon
+ALTER TABLE "interactions" ADD COLUMN "client_uuid" VARCHAR(64) UNIQUE DEFAULT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "banner_thumbnail_key";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "banner_key";
+
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "product_thumbnail_key";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "product_image_key";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "thumbnail_key";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "image_key";
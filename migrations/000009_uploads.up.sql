@@ -0,0 +1,8 @@
+-- Clés des fichiers dans le stockage, les URLs signées sont générées à la lecture
+ALTER TABLE "stands" ADD COLUMN "image_key" VARCHAR(255) DEFAULT NULL;
+ALTER TABLE "stands" ADD COLUMN "thumbnail_key" VARCHAR(255) DEFAULT NULL;
+ALTER TABLE "stands" ADD COLUMN "product_image_key" VARCHAR(255) DEFAULT NULL;
+ALTER TABLE "stands" ADD COLUMN "product_thumbnail_key" VARCHAR(255) DEFAULT NULL;
+
+ALTER TABLE "kermesses" ADD COLUMN "banner_key" VARCHAR(255) DEFAULT NULL;
+ALTER TABLE "kermesses" ADD COLUMN "banner_thumbnail_key" VARCHAR(255) DEFAULT NULL;
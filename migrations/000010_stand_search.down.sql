@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "stands_tags_idx";
+DROP INDEX IF EXISTS "stands_search_idx";
+DROP FUNCTION IF EXISTS stand_search_vector(TEXT, TEXT, TEXT, TEXT, TEXT[]);
+
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "product_name";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "tags";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "category";
@@ -0,0 +1,15 @@
+ALTER TABLE "stands" ADD COLUMN "category" VARCHAR(50) DEFAULT NULL;
+ALTER TABLE "stands" ADD COLUMN "tags" TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE "stands" ADD COLUMN "product_name" VARCHAR(255) DEFAULT NULL;
+
+-- Document de recherche d'un stand : le nom et le produit pèsent plus que les tags, puis la description.
+-- Déclarée IMMUTABLE pour pouvoir être indexée (array_to_string ne l'est pas).
+CREATE FUNCTION stand_search_vector(name TEXT, description TEXT, product_name TEXT, category TEXT, tags TEXT[])
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('french', COALESCE(name, '') || ' ' || COALESCE(product_name, '')), 'A')
+        || setweight(to_tsvector('french', COALESCE(category, '') || ' ' || array_to_string(tags, ' ')), 'B')
+        || setweight(to_tsvector('french', COALESCE(description, '')), 'C')
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX "stands_search_idx" ON "stands" USING GIN (stand_search_vector("name", "description", "product_name", "category", "tags"));
+CREATE INDEX "stands_tags_idx" ON "stands" USING GIN ("tags");
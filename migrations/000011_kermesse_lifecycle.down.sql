@@ -0,0 +1,10 @@
+ALTER TABLE "kermesses" DROP CONSTRAINT IF EXISTS "kermesses_dates_check";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "ends_at";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "starts_at";
+
+ALTER TABLE "kermesses" ALTER COLUMN "statut" DROP DEFAULT;
+ALTER TABLE "kermesses" ALTER COLUMN "statut" TYPE statut_enum
+  USING (CASE WHEN "statut" IN ('CLOSED', 'ARCHIVED') THEN 'ENDED' ELSE 'STARTED' END)::statut_enum;
+ALTER TABLE "kermesses" ALTER COLUMN "statut" SET DEFAULT 'STARTED';
+
+DROP TYPE IF EXISTS kermesse_statut_enum;
@@ -0,0 +1,11 @@
+CREATE TYPE kermesse_statut_enum AS ENUM ('DRAFT', 'PUBLISHED', 'OPEN', 'CLOSED', 'ARCHIVED');
+
+-- Les kermesses en cours restent ouvertes, les kermesses terminées sont clôturées
+ALTER TABLE "kermesses" ALTER COLUMN "statut" DROP DEFAULT;
+ALTER TABLE "kermesses" ALTER COLUMN "statut" TYPE kermesse_statut_enum
+  USING (CASE "statut" WHEN 'STARTED' THEN 'OPEN' ELSE 'CLOSED' END)::kermesse_statut_enum;
+ALTER TABLE "kermesses" ALTER COLUMN "statut" SET DEFAULT 'DRAFT';
+
+ALTER TABLE "kermesses" ADD COLUMN "starts_at" TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE "kermesses" ADD COLUMN "ends_at" TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE "kermesses" ADD CONSTRAINT "kermesses_dates_check" CHECK ("starts_at" IS NULL OR "ends_at" IS NULL OR "ends_at" > "starts_at");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "job_runs";
+DROP TYPE IF EXISTS job_run_statut_enum;
@@ -0,0 +1,13 @@
+CREATE TYPE job_run_statut_enum AS ENUM ('SUCCESS', 'FAILED');
+
+-- Historique des exécutions des tâches planifiées
+CREATE TABLE "job_runs" (
+  "id" SERIAL PRIMARY KEY,
+  "name" VARCHAR(100) NOT NULL,
+  "statut" job_run_statut_enum NOT NULL,
+  "message" TEXT NOT NULL DEFAULT '',
+  "started_at" TIMESTAMPTZ NOT NULL,
+  "finished_at" TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX "job_runs_name_started_at_idx" ON "job_runs" ("name", "started_at" DESC);
@@ -0,0 +1,10 @@
+-- Codes d'invitation permettant aux parents d'inscrire eux-mêmes leurs enfants
+CREATE TABLE "kermesse_invitations" (
+  "id" SERIAL PRIMARY KEY,
+  "kermesse_id" INTEGER NOT NULL REFERENCES "kermesses"("id") ON DELETE CASCADE,
+  "code" VARCHAR(16) NOT NULL UNIQUE,
+  "max_uses" INTEGER DEFAULT NULL CHECK ("max_uses" > 0),
+  "uses" INTEGER NOT NULL DEFAULT 0,
+  "expires_at" TIMESTAMPTZ DEFAULT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
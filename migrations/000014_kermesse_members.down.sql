@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "kermesse_members";
+DROP TYPE IF EXISTS kermesse_member_role_enum;
@@ -0,0 +1,14 @@
+CREATE TYPE kermesse_member_role_enum AS ENUM ('OWNER', 'CO_ORGANISATEUR', 'CAISSIER', 'LECTEUR');
+
+-- Équipe de chaque kermesse, le créateur en est le propriétaire
+CREATE TABLE "kermesse_members" (
+  "id" SERIAL PRIMARY KEY,
+  "kermesse_id" INTEGER NOT NULL REFERENCES "kermesses"("id") ON DELETE CASCADE,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+  "role" kermesse_member_role_enum NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE ("kermesse_id", "user_id")
+);
+
+INSERT INTO "kermesse_members" ("kermesse_id", "user_id", "role")
+SELECT "id", "user_id", 'OWNER' FROM "kermesses";
@@ -0,0 +1 @@
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "is_template";
@@ -0,0 +1,2 @@
+-- Un modèle conserve la configuration d'une kermesse pour la recréer les années suivantes
+ALTER TABLE "kermesses" ADD COLUMN "is_template" BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS "tombolas_kermesse_id_key";
+ALTER TABLE "tombolas" ADD CONSTRAINT "tombolas_kermesse_id_key" UNIQUE ("kermesse_id");
+DROP INDEX IF EXISTS "stands_user_id_key";
+ALTER TABLE "stands" ADD CONSTRAINT "stands_user_id_key" UNIQUE ("user_id");
+DROP INDEX IF EXISTS "users_email_key";
+ALTER TABLE "users" ADD CONSTRAINT "users_email_key" UNIQUE ("email");
+
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "anonymised_at";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "archived_at";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "tombolas" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
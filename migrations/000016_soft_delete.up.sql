@@ -0,0 +1,18 @@
+-- Suppression logique : les lignes supprimées restent pour l'historique mais disparaissent des requêtes
+ALTER TABLE "users" ADD COLUMN "deleted_at" TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE "stands" ADD COLUMN "deleted_at" TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE "tombolas" ADD COLUMN "deleted_at" TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE "kermesses" ADD COLUMN "deleted_at" TIMESTAMPTZ DEFAULT NULL;
+
+-- Date d'archivage, point de départ de la durée de conservation
+ALTER TABLE "kermesses" ADD COLUMN "archived_at" TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE "kermesses" ADD COLUMN "anonymised_at" TIMESTAMPTZ DEFAULT NULL;
+UPDATE "kermesses" SET "archived_at" = COALESCE("ends_at", CURRENT_TIMESTAMP) WHERE "statut" = 'ARCHIVED';
+
+-- L'unicité ne porte plus que sur les lignes actives
+ALTER TABLE "users" DROP CONSTRAINT "users_email_key";
+CREATE UNIQUE INDEX "users_email_key" ON "users" ("email") WHERE "deleted_at" IS NULL;
+ALTER TABLE "stands" DROP CONSTRAINT "stands_user_id_key";
+CREATE UNIQUE INDEX "stands_user_id_key" ON "stands" ("user_id") WHERE "deleted_at" IS NULL;
+ALTER TABLE "tombolas" DROP CONSTRAINT "tombolas_kermesse_id_key";
+CREATE UNIQUE INDEX "tombolas_kermesse_id_key" ON "tombolas" ("kermesse_id") WHERE "deleted_at" IS NULL;
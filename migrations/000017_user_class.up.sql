@@ -0,0 +1,2 @@
+-- Classe de l'enfant, pour regrouper le classement des points
+ALTER TABLE "users" ADD COLUMN "class" VARCHAR(50) DEFAULT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE "kermesses_stands" DROP COLUMN IF EXISTS "zone";
+ALTER TABLE "kermesses_stands" DROP COLUMN IF EXISTS "pos_y";
+ALTER TABLE "kermesses_stands" DROP COLUMN IF EXISTS "pos_x";
+
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "map_thumbnail_key";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "map_key";
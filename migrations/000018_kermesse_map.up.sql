@@ -0,0 +1,8 @@
+-- Plan de la cour : image de fond et emplacement des stands
+ALTER TABLE "kermesses" ADD COLUMN "map_key" VARCHAR(255) DEFAULT NULL;
+ALTER TABLE "kermesses" ADD COLUMN "map_thumbnail_key" VARCHAR(255) DEFAULT NULL;
+
+-- Coordonnées relatives à l'image (0 à 1), et/ou zone nommée
+ALTER TABLE "kermesses_stands" ADD COLUMN "pos_x" DOUBLE PRECISION DEFAULT NULL CHECK ("pos_x" BETWEEN 0 AND 1);
+ALTER TABLE "kermesses_stands" ADD COLUMN "pos_y" DOUBLE PRECISION DEFAULT NULL CHECK ("pos_y" BETWEEN 0 AND 1);
+ALTER TABLE "kermesses_stands" ADD COLUMN "zone" VARCHAR(100) DEFAULT NULL;
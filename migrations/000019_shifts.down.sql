@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "shift_volunteers";
+DROP TABLE IF EXISTS "shifts";
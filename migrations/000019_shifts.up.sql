@@ -0,0 +1,23 @@
+-- Créneaux de bénévoles par stand et par kermesse
+CREATE TABLE "shifts" (
+  "id" SERIAL PRIMARY KEY,
+  "kermesse_id" INTEGER NOT NULL REFERENCES "kermesses"("id") ON DELETE CASCADE,
+  "stand_id" INTEGER NOT NULL REFERENCES "stands"("id"),
+  "starts_at" TIMESTAMPTZ NOT NULL,
+  "ends_at" TIMESTAMPTZ NOT NULL,
+  "headcount" INTEGER NOT NULL CHECK ("headcount" > 0),
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK ("ends_at" > "starts_at")
+);
+
+CREATE INDEX "shifts_stand_id_idx" ON "shifts" ("stand_id", "starts_at");
+
+-- Parents inscrits sur un créneau, reminded_at marque l'envoi du rappel
+CREATE TABLE "shift_volunteers" (
+  "id" SERIAL PRIMARY KEY,
+  "shift_id" INTEGER NOT NULL REFERENCES "shifts"("id") ON DELETE CASCADE,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+  "reminded_at" TIMESTAMPTZ DEFAULT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE ("shift_id", "user_id")
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "payments";
+DROP TYPE IF EXISTS payment_source_enum;
+
+DROP TABLE IF EXISTS "expenses";
+DROP TYPE IF EXISTS expense_category_enum;
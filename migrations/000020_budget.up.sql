@@ -0,0 +1,33 @@
+-- Dépenses des organisateurs, montants en centimes d'euro
+CREATE TYPE expense_category_enum AS ENUM ('STOCK', 'LOTS', 'LOCATION', 'AUTRE');
+
+CREATE TABLE "expenses" (
+  "id" SERIAL PRIMARY KEY,
+  "kermesse_id" INTEGER NOT NULL REFERENCES "kermesses"("id") ON DELETE CASCADE,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+  "category" expense_category_enum NOT NULL,
+  "label" VARCHAR(255) NOT NULL,
+  "amount" INTEGER NOT NULL CHECK ("amount" > 0),
+  "spent_at" DATE NOT NULL DEFAULT CURRENT_DATE,
+  "receipt_key" VARCHAR(255) DEFAULT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "expenses_kermesse_id_idx" ON "expenses" ("kermesse_id");
+
+-- Achats de jetons payés en euros, par Stripe ou en espèces à la caisse
+CREATE TYPE payment_source_enum AS ENUM ('STRIPE', 'CASH');
+
+CREATE TABLE "payments" (
+  "id" SERIAL PRIMARY KEY,
+  "kermesse_id" INTEGER DEFAULT NULL REFERENCES "kermesses"("id") ON DELETE SET NULL,
+  "user_id" INTEGER DEFAULT NULL REFERENCES "users"("id"),
+  "recorded_by" INTEGER DEFAULT NULL REFERENCES "users"("id"),
+  "source" payment_source_enum NOT NULL,
+  "amount" INTEGER NOT NULL CHECK ("amount" >= 0),
+  "jetons" INTEGER NOT NULL DEFAULT 0 CHECK ("jetons" >= 0),
+  "stripe_session_id" VARCHAR(255) DEFAULT NULL UNIQUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "payments_kermesse_id_idx" ON "payments" ("kermesse_id");
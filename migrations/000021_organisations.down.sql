@@ -0,0 +1,5 @@
+ALTER TABLE "stands" DROP COLUMN IF EXISTS "organisation_id";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "organisation_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "organisation_id";
+
+DROP TABLE IF EXISTS "organisations";
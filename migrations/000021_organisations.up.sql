@@ -0,0 +1,26 @@
+-- Écoles hébergées par l'API, créées par l'équipe d'hébergement
+CREATE TABLE "organisations" (
+  "id" SERIAL PRIMARY KEY,
+  "name" VARCHAR(255) NOT NULL,
+  "slug" VARCHAR(100) NOT NULL UNIQUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Les données existantes sont rattachées à une première école
+INSERT INTO "organisations" ("name", "slug") VALUES ('École', 'ecole');
+
+ALTER TABLE "users" ADD COLUMN "organisation_id" INTEGER REFERENCES "organisations"("id");
+ALTER TABLE "kermesses" ADD COLUMN "organisation_id" INTEGER REFERENCES "organisations"("id");
+ALTER TABLE "stands" ADD COLUMN "organisation_id" INTEGER REFERENCES "organisations"("id");
+
+UPDATE "users" SET "organisation_id" = (SELECT MIN("id") FROM "organisations");
+UPDATE "kermesses" SET "organisation_id" = (SELECT MIN("id") FROM "organisations");
+UPDATE "stands" SET "organisation_id" = (SELECT MIN("id") FROM "organisations");
+
+ALTER TABLE "users" ALTER COLUMN "organisation_id" SET NOT NULL;
+ALTER TABLE "kermesses" ALTER COLUMN "organisation_id" SET NOT NULL;
+ALTER TABLE "stands" ALTER COLUMN "organisation_id" SET NOT NULL;
+
+CREATE INDEX "users_organisation_id_idx" ON "users" ("organisation_id");
+CREATE INDEX "kermesses_organisation_id_idx" ON "kermesses" ("organisation_id");
+CREATE INDEX "stands_organisation_id_idx" ON "stands" ("organisation_id");
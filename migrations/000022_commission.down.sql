@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "treasury_transactions";
+DROP TYPE IF EXISTS treasury_transaction_type_enum;
+
+ALTER TABLE "interactions" DROP COLUMN IF EXISTS "refunded_at";
+ALTER TABLE "interactions" DROP COLUMN IF EXISTS "commission";
+ALTER TABLE "interactions" DROP COLUMN IF EXISTS "quantity";
+
+ALTER TABLE "kermesses_stands" DROP COLUMN IF EXISTS "commission_rate";
+
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "treasury_jetons";
+ALTER TABLE "kermesses" DROP COLUMN IF EXISTS "commission_rate";
@@ -0,0 +1,24 @@
+-- Commission de l'école sur les ventes des stands, en pourcentage
+ALTER TABLE "kermesses" ADD COLUMN "commission_rate" INTEGER NOT NULL DEFAULT 0 CHECK ("commission_rate" BETWEEN 0 AND 100);
+ALTER TABLE "kermesses" ADD COLUMN "treasury_jetons" INTEGER NOT NULL DEFAULT 0;
+
+-- Taux propre à un stand dans la kermesse, NULL pour appliquer celui de la kermesse
+ALTER TABLE "kermesses_stands" ADD COLUMN "commission_rate" INTEGER DEFAULT NULL CHECK ("commission_rate" BETWEEN 0 AND 100);
+
+-- Part de la vente retenue pour la trésorerie, conservée pour rembourser dans les mêmes proportions
+ALTER TABLE "interactions" ADD COLUMN "quantity" INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE "interactions" ADD COLUMN "commission" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "interactions" ADD COLUMN "refunded_at" TIMESTAMP DEFAULT NULL;
+
+CREATE TYPE treasury_transaction_type_enum AS ENUM ('COMMISSION', 'REFUND');
+
+CREATE TABLE "treasury_transactions" (
+  "id" SERIAL PRIMARY KEY,
+  "kermesse_id" INTEGER NOT NULL REFERENCES "kermesses"("id") ON DELETE CASCADE,
+  "type" treasury_transaction_type_enum NOT NULL,
+  "amount" INTEGER NOT NULL,
+  "interaction_id" INTEGER DEFAULT NULL REFERENCES "interactions"("id") ON DELETE SET NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "treasury_transactions_kermesse_id_idx" ON "treasury_transactions" ("kermesse_id");
@@ -0,0 +1,6 @@
+ALTER TABLE "treasury_transactions" DROP COLUMN IF EXISTS "ticket_id";
+ALTER TABLE "tickets" DROP COLUMN IF EXISTS "price";
+
+-- Postgres ne sait pas retirer une valeur d'enum : on garde les valeurs, sans plus les utiliser
+DELETE FROM "treasury_transactions" WHERE "type" IN ('TICKET', 'TICKET_REFUND');
+UPDATE "tombolas" SET "statut" = 'ENDED' WHERE "statut" = 'CANCELLED';
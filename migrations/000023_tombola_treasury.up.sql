@@ -0,0 +1,10 @@
+-- Une tombola annulée rembourse ses tickets depuis la trésorerie de la kermesse
+ALTER TYPE statut_enum ADD VALUE IF NOT EXISTS 'CANCELLED';
+ALTER TYPE treasury_transaction_type_enum ADD VALUE IF NOT EXISTS 'TICKET';
+ALTER TYPE treasury_transaction_type_enum ADD VALUE IF NOT EXISTS 'TICKET_REFUND';
+
+-- Prix payé pour le ticket, remboursé tel quel en cas d'annulation
+ALTER TABLE "tickets" ADD COLUMN "price" INTEGER NOT NULL DEFAULT 0;
+UPDATE "tickets" t SET "price" = tb."price" FROM "tombolas" tb WHERE t."tombola_id" = tb."id";
+
+ALTER TABLE "treasury_transactions" ADD COLUMN "ticket_id" INTEGER DEFAULT NULL REFERENCES "tickets"("id") ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE "tombolas" DROP COLUMN IF EXISTS "drawn_at";
+ALTER TABLE "tombolas" DROP COLUMN IF EXISTS "seed";
+ALTER TABLE "tombolas" DROP COLUMN IF EXISTS "seed_hash";
@@ -0,0 +1,5 @@
+-- Tirage vérifiable : l'organisateur publie l'empreinte de sa graine avant la fin des ventes
+-- et ne la révèle qu'au tirage
+ALTER TABLE "tombolas" ADD COLUMN "seed_hash" CHAR(64) DEFAULT NULL;
+ALTER TABLE "tombolas" ADD COLUMN "seed" VARCHAR(128) DEFAULT NULL;
+ALTER TABLE "tombolas" ADD COLUMN "drawn_at" TIMESTAMP DEFAULT NULL;
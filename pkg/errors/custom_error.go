@@ -15,10 +15,7 @@ func (ce CustomError) StatusCode() int {
 	switch ce.Key {
 	case BadRequest:
 		return http.StatusBadRequest
-	case Unauthorized:
-	case InvalidCredentials:
-	case InvalidCode:
-	case ExpiredCode:
+	case Unauthorized, InvalidCredentials, InvalidCode, ExpiredCode:
 		return http.StatusUnauthorized
 	case Forbidden:
 		return http.StatusForbidden
@@ -26,8 +23,7 @@ func (ce CustomError) StatusCode() int {
 		return http.StatusNotFound
 	case MethodNotAllowed:
 		return http.StatusMethodNotAllowed
-	case Conflict:
-	case EmailAlreadyExists:
+	case Conflict, EmailAlreadyExists:
 		return http.StatusConflict
 	case UnsupportedMediaType:
 		return http.StatusUnsupportedMediaType
@@ -41,10 +37,7 @@ func (ce CustomError) StatusCode() int {
 		return http.StatusServiceUnavailable
 	case GatewayTimeout:
 		return http.StatusGatewayTimeout
-	case InternalServerError:
 	default:
 		return http.StatusInternalServerError
 	}
-
-	return http.StatusInternalServerError
 }
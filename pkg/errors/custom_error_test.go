@@ -0,0 +1,31 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{BadRequest, http.StatusBadRequest},
+		{Unauthorized, http.StatusUnauthorized},
+		{InvalidCredentials, http.StatusUnauthorized},
+		{InvalidCode, http.StatusUnauthorized},
+		{ExpiredCode, http.StatusUnauthorized},
+		{Forbidden, http.StatusForbidden},
+		{NotFound, http.StatusNotFound},
+		{Conflict, http.StatusConflict},
+		{EmailAlreadyExists, http.StatusConflict},
+		{TooManyRequests, http.StatusTooManyRequests},
+		{InternalServerError, http.StatusInternalServerError},
+		{"INCONNUE", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := (CustomError{Key: tt.key}).StatusCode(); got != tt.want {
+			t.Errorf("StatusCode(%s) = %d, attendu %d", tt.key, got, tt.want)
+		}
+	}
+}
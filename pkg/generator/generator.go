@@ -3,6 +3,7 @@ package generator
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"math/big"
 )
 
 func RandomPassword(length int) (string, error) {
@@ -25,3 +26,22 @@ func RandomPassword(length int) (string, error) {
 	// Trim to desired length
 	return password[:length], nil
 }
+
+// Alphabet sans caractères ambigus (0/O, 1/I/L) pour les codes saisis à la main
+const codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+func RandomCode(length int) (string, error) {
+	alphabetSize := big.NewInt(int64(len(codeAlphabet)))
+
+	// Tirage uniforme de chaque caractère, sans le biais d'un modulo sur un octet
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,84 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	MaxSize       = 5 << 20 // 5 Mo
+	MaxPixels     = 25_000_000
+	ThumbnailSize = 320
+)
+
+var (
+	ErrTooLarge           = errors.New("L'image ne doit pas dépasser 5 Mo")
+	ErrUnsupportedType    = errors.New("Seules les images JPEG et PNG sont acceptées")
+	ErrInvalidImage       = errors.New("Image invalide")
+	ErrTooManyPixels      = errors.New("L'image ne doit pas dépasser 25 mégapixels")
+	allowedContentTypes   = map[string]string{"image/jpeg": "jpg", "image/png": "png"}
+	thumbnailJPEGEncoding = &jpeg.Options{Quality: 80}
+)
+
+// Image validée, prête à être stockée avec sa vignette JPEG.
+type Image struct {
+	Ext       string
+	Data      []byte
+	Thumbnail []byte
+}
+
+// Vérifie la taille, le type réel (et non celui annoncé par le client)
+// et les dimensions, puis génère la vignette.
+func Prepare(data []byte) (Image, error) {
+	if len(data) > MaxSize {
+		return Image{}, ErrTooLarge
+	}
+	ext, ok := allowedContentTypes[http.DetectContentType(data)]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	decodeConfig, decode := jpeg.DecodeConfig, jpeg.Decode
+	if ext == "png" {
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	}
+
+	// Les dimensions annoncées par l'en-tête sont vérifiées avant de décoder :
+	// un petit fichier peut déclarer une image énorme
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+
+	src, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+
+	// Fond blanc pour les PNG transparents, le JPEG n'ayant pas de canal alpha
+	thumbnail := Thumbnail(src, ThumbnailSize)
+	bounds := thumbnail.Bounds()
+	flattened := image.NewRGBA(bounds)
+	draw.Draw(flattened, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flattened, bounds, thumbnail, bounds.Min, draw.Over)
+
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, flattened, thumbnailJPEGEncoding); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		Ext:       ext,
+		Data:      data,
+		Thumbnail: buf.Bytes(),
+	}, nil
+}
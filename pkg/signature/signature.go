@@ -0,0 +1,20 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, payload []byte, signature string) bool {
+	expected := Sign(secret, payload)
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,61 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrUnsupported = errors.New("streaming non supporté")
+
+// Flux Server-Sent Events : chaque événement est envoyé immédiatement au client.
+type Stream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func NewStream(w http.ResponseWriter) (*Stream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &Stream{
+		w:       w,
+		flusher: flusher,
+	}, nil
+}
+
+func (s *Stream) Send(event string, data []byte) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	if _, err := s.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// Commentaire ignoré par le client, pour garder la connexion ouverte derrière un proxy.
+func (s *Stream) Ping() error {
+	if _, err := s.w.Write([]byte(": ping\n\n")); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
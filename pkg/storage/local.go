@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chall-goflutter-api/pkg/signature"
+)
+
+// Stockage sur le système de fichiers local, servi par la route /files.
+type Local struct {
+	dir     string
+	baseURL string
+	secret  string
+}
+
+// La clé signe les URLs des fichiers : vide, n'importe qui pourrait en forger.
+func NewLocal(dir, baseURL, secret string) (*Local, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("la clé de signature des fichiers est manquante")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+func (l *Local) Put(key string, data []byte) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (l *Local) Open(key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (l *Local) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) URL(key string, ttl time.Duration) string {
+	expires := time.Now().Add(ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", signature.Sign(l.secret, signedPayload(key, expires)))
+
+	return fmt.Sprintf("%s/files/%s?%s", l.baseURL, key, query.Encode())
+}
+
+func (l *Local) Verify(key string, expires int64, sig string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+
+	return signature.Verify(l.secret, signedPayload(key, expires), sig)
+}
+
+// Empêche une clé de sortir du dossier de stockage
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid key: %s", key)
+	}
+
+	return filepath.Join(l.dir, clean), nil
+}
+
+func signedPayload(key string, expires int64) []byte {
+	return []byte(fmt.Sprintf("%s:%d", key, expires))
+}
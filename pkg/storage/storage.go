@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"io"
+	"time"
+)
+
+// Stockage des fichiers envoyés par les utilisateurs (photos, bannières).
+type Storage interface {
+	Put(key string, data []byte) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// URL signée donnant accès au fichier pendant ttl
+	URL(key string, ttl time.Duration) string
+	Verify(key string, expires int64, signature string) bool
+}
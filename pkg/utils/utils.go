@@ -3,22 +3,44 @@ package utils
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 )
 
 func GetIntFromMap(input map[string]interface{}, key string) (int, error) {
 	value, ok := input[key]
 	if !ok || value == nil {
-		return 0, fmt.Errorf("%s is missing or nil", key)
+		return 0, fmt.Errorf("%s est manquant", key)
 	}
 
 	floatValue, ok := value.(float64)
 	if !ok {
-		return 0, fmt.Errorf("%s is not a valid number", key)
+		return 0, fmt.Errorf("%s n'est pas un nombre valide", key)
 	}
 
 	return int(floatValue), nil
 }
 
+// Renvoie nil si la clé est absente ou nulle, sinon la date au format RFC 3339.
+func GetTimeFromMap(input map[string]interface{}, key string) (*time.Time, error) {
+	value, ok := input[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	stringValue, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s n'est pas une date valide", key)
+	}
+	timeValue, err := time.Parse(time.RFC3339, stringValue)
+	if err != nil {
+		return nil, fmt.Errorf("%s n'est pas une date valide", key)
+	}
+
+	return &timeValue, nil
+}
+
 func GetQueryParams(r *http.Request) map[string]interface{} {
 	query := r.URL.Query()
 	params := map[string]interface{}{}
@@ -33,3 +55,37 @@ func GetQueryParams(r *http.Request) map[string]interface{} {
 
 	return params
 }
+
+// Lit un paramètre de requête entier, les valeurs de GetQueryParams étant des chaînes.
+func GetIntFromParams(params map[string]interface{}, key string) (int, error) {
+	value, ok := params[key].(string)
+	if !ok {
+		return 0, fmt.Errorf("%s n'est pas un nombre valide", key)
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s n'est pas un nombre valide", key)
+	}
+
+	return intValue, nil
+}
+
+// Variable d'environnement obligatoire, typiquement une clé de signature :
+// vide, elle laisserait n'importe qui signer à la place du serveur.
+func RequireEnv(key string) (string, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return "", fmt.Errorf("la variable d'environnement %s doit être définie", key)
+	}
+
+	return value, nil
+}
+
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}